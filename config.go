@@ -26,11 +26,9 @@ package config
 
 import (
   "encoding/json"
-  "encoding/xml"
   "fmt"
-  "gopkg.in/v1/yaml"
+  "io/fs"
   "io/ioutil"
-  "reflect"
   "regexp"
   "strings"
 
@@ -47,70 +45,101 @@ func From(c interface{}) (Config, error) {
   return FromQuick(c)
 }
 
+// FromQuick converts map or structure into the Config. Nested maps and slices
+// are converted into Config and ConfigArr, scalar map keys of any type
+// (e.g. YAML integer or boolean keys) are converted into strings.
+//
+// Struct fields are named by the `config:"name,omitempty,inline"` tags like
+// in Config.Decode or by the `field` tags of the earlier versions, types
+// implementing encoding.TextMarshaler or json.Marshaler are converted by them.
 func FromQuick(c interface{}) (Config, error) {
-  if nil == c {
-    return make(Config), nil
+  return fromMap(c, "")
+}
+
+func New() Config {
+  return make(Config)
+}
+
+// FromFile loads config file, empty ftype means the format is selected
+// by the file extension or by the content.
+//
+// Top level directives $extends and $include (file name, glob or list of them)
+// load other files relative to the current one: $extends files are the base
+// overridden by the file values, $include files are merged over them in order.
+func FromFile(filename, ftype string) (Config, error) {
+  conf, _, err := newFileLoader().load(filename, ftype)
+  return conf, err
+}
+
+// FromFS loads config file from the file system (e.g. embed.FS) like FromFile,
+// directives are resolved within the file system
+func FromFS(fsys fs.FS, filename, ftype string) (Config, error) {
+  conf, _, err := newFSLoader(fsys).load(filename, ftype)
+  return conf, err
+}
+
+// FromData decodes data of the format, empty dtype means
+// the format is detected by the content
+func FromData(data []byte, dtype string) (conf Config, err error) {
+  if "" == dtype {
+    _, conf, err = sniffData(data)
+    return
   }
 
-  sm, err := gocast.ToSiMap(c, "field", true)
+  codec := FormatCodec(dtype)
+  if nil == codec {
+    return nil, ErrInvalidConfigFormat
+  }
+
+  info, err := codec.Decode(data)
   if nil != err {
     return nil, err
   }
-
-  conf := make(Config)
-  for k, v := range sm {
-    if nil == v {
-      conf[k] = v
-      continue
-    }
-
-    t := reflect.TypeOf(v)
-    switch t.Kind() {
-    case reflect.Map:
-      conf[k], _ = FromQuick(v)
-      break
-    case reflect.Slice:
-      conf[k], _ = FromSliceQuick(v)
-      break
-    default:
-      conf[k] = v
-      break
-    }
+  if c, ok := info.(Config); ok {
+    return c, nil
   }
-  return conf, nil
+  return From(info)
 }
 
-func New() Config {
-  return make(Config)
-}
-
-func FromFile(filename, ftype string) (Config, error) {
+// FromFileMulti loads all documents of the file, see FromDataMulti
+func FromFileMulti(filename, ftype string) ([]Config, error) {
   bytes, err := ioutil.ReadFile(filename)
   if nil != err {
     return nil, err
   }
-  return FromData(bytes, ftype)
+  if "" == ftype {
+    ftype = FormatByExtension(filename)
+  }
+  return FromDataMulti(bytes, ftype)
 }
 
-func FromData(data []byte, dtype string) (conf Config, err error) {
-  var info interface{}
-  switch strings.ToLower(dtype) {
-  case "json":
-    err = json.Unmarshal(data, &info)
-    break
-  case "xml":
-    err = xml.Unmarshal(data, &info)
-    break
-  case "yaml":
-    err = yaml.Unmarshal(data, &info)
-    break
-  default:
-    err = ErrInvalidConfigFormat
+// FromDataMulti decodes each document of the stream (e.g. YAML documents
+// separated by ---) into the separate config, empty documents are empty configs.
+// Formats without multi-document support return the single config.
+func FromDataMulti(data []byte, dtype string) ([]Config, error) {
+  codec, ok := FormatCodec(dtype).(MultiCodec)
+  if !ok {
+    conf, err := FromData(data, dtype)
+    if nil != err {
+      return nil, err
+    }
+    return []Config{conf}, nil
   }
-  if nil == err {
-    conf, err = From(info)
+
+  docs, err := codec.DecodeMulti(data)
+  if nil != err {
+    return nil, err
   }
-  return
+
+  result := make([]Config, 0, len(docs))
+  for _, info := range docs {
+    conf, err := From(info)
+    if nil != err {
+      return nil, err
+    }
+    result = append(result, conf)
+  }
+  return result, nil
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -143,12 +172,12 @@ func (conf Config) GetPath(path []string) (interface{}, error) {
       for _, v := range curConf {
         switch a := v.(type) {
         case ConfigArr:
-          if r, err := a.GetPath(path[i:]); nil == err {
+          if r, err := a.GetPath(path[i+1:]); nil == err {
             response = append(response, r)
           }
           break
         case Config:
-          if r, err := a.GetPath(path[i:]); nil == err {
+          if r, err := a.GetPath(path[i+1:]); nil == err {
             response = append(response, r)
           }
           break
@@ -165,8 +194,7 @@ func (conf Config) GetPath(path []string) (interface{}, error) {
 
         switch a := it.(type) {
         case ConfigArr:
-          return a.GetPath(path[i:])
-          break
+          return a.GetPath(path[i+1:])
         case Config:
           curConf = a
           break
@@ -212,71 +240,16 @@ func (conf Config) Set(path string, value interface{}) Config {
   return conf.SetPath(strings.Split(path, "."), value)
 }
 
-func (conf Config) SetPath(fullpath []string, value interface{}) Config {
-  if len(fullpath) < 1 {
-    return conf
+// SetPath sets the value creating intermediate Config and ConfigArr items.
+// Digit keys are array indexes if they continue the array (0 for the new one),
+// other digit keys are the object keys, e.g. codes.404.
+func (conf Config) SetPath(path []string, value interface{}) Config {
+  if len(path) < 1 || isArrayOperator(path[0]) {
+    return conf // Invalid path
   }
-
-  key := fullpath[len(fullpath)-1]
-  path := fullpath[:len(fullpath)-1]
-  curConf := conf
-
-  if len(path) > 0 {
-    if isArrayChain(fullpath[0]) {
-      return conf // Invalid path
-    }
-
-    for i, key := range path {
-      isArray := false
-      if i < len(path)-1 {
-        isArray = isArrayChain(path[i+1])
-      }
-
-      if it, ok := curConf[key]; !ok || nil == it { // We have path key
-        if isArray {
-          newConf := make(ConfigArr, 0)
-          curConf[key] = newConf.SetPath(fullpath[i:], value)
-          return conf
-        } else {
-          newConf := make(Config)
-          curConf[key] = newConf
-          curConf = newConf
-        }
-        continue
-      } else { // It`s something eles
-        switch a := it.(type) {
-        case ConfigArr:
-          if isArray {
-            a.SetPath(fullpath[i:], value)
-            return conf
-          } else {
-            newConf := make(Config)
-            curConf[key] = newConf
-            curConf = newConf
-          }
-          break
-        case Config:
-          if isArray {
-            newConf := make(ConfigArr, 0)
-            curConf[key] = newConf.SetPath(fullpath[i:], value)
-            return conf
-          } else {
-            curConf = a
-          }
-          break
-        default: // Replace any other value
-          newConf := make(Config)
-          curConf[key] = newConf
-          curConf = newConf
-        }
-      }
-    }
-  }
-
-  if isArrayChain(key) {
-    // Invalid! I cant set as for array
-  } else {
-    curConf[key] = prepareValueForSet(value)
+  it, ok := conf[path[0]]
+  if it = setItem(it, path[1:], value); ok || nil != it || len(path) < 2 {
+    conf[path[0]] = it
   }
   return conf
 }
@@ -308,6 +281,18 @@ func (conf Config) Update(conf2 Config) Config {
   return conf
 }
 
+// Copy returns deep copy of the config
+func (conf Config) Copy() Config {
+  if nil == conf {
+    return nil
+  }
+  c := make(Config, len(conf))
+  for k, v := range conf {
+    c[k] = copyItem(v)
+  }
+  return c
+}
+
 func (conf Config) UpdateByPath(path string) Config {
   conf2, _ := conf.Get(path)
   if nil != conf2 {
@@ -332,6 +317,29 @@ func (conf Config) JSONPrettify() ([]byte, error) {
   return json.MarshalIndent(conf, "", "\t")
 }
 
+// Encode config into the format registered by RegisterFormat.
+// Built-in encoders write keys in the sorted order.
+func (conf Config) Encode(format string) ([]byte, error) {
+  codec := FormatCodec(format)
+  if nil == codec {
+    return nil, ErrInvalidConfigFormat
+  }
+  return codec.Encode(conf)
+}
+
+// ToFile writes config file, empty format means
+// the format is selected by the file extension
+func (conf Config) ToFile(filename, format string) error {
+  if "" == format {
+    format = FormatByExtension(filename)
+  }
+  data, err := conf.Encode(format)
+  if nil != err {
+    return err
+  }
+  return ioutil.WriteFile(filename, data, 0644)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 /// Processing
 ///////////////////////////////////////////////////////////////////////////////
@@ -25,38 +25,14 @@
 package config
 
 import (
-  "reflect"
   "strconv"
   "strings"
-
-  "github.com/demdxx/gocast"
 )
 
 type ConfigArr []interface{}
 
 func FromSliceQuick(c interface{}) (ConfigArr, error) {
-  if nil == c {
-    return make(ConfigArr, 0), nil
-  }
-
-  conf := make(ConfigArr, 0)
-  for _, v := range gocast.ToInterfaceSlice(c) {
-    t := reflect.TypeOf(v)
-    switch t.Kind() {
-    case reflect.Map:
-      nc, _ := FromQuick(v)
-      conf = append(conf, nc)
-      break
-    case reflect.Slice:
-      nc, _ := FromSliceQuick(v)
-      conf = append(conf, nc)
-      break
-    default:
-      conf = append(conf, v)
-      break
-    }
-  }
-  return conf, nil
+  return fromSlice(c, "")
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -102,20 +78,17 @@ func (conf ConfigArr) GetPath(path []string) (interface{}, error) {
     index, _ := strconv.Atoi(key)
     if index < len(conf) {
       it := conf[index]
-      if len(path) == 1 {
+      if len(path) < 1 {
         return it, nil
       }
 
       switch a := it.(type) {
       case Config:
         return a.GetPath(path)
-        break
       case ConfigArr:
         return a.GetPath(path)
-        break
       default:
         return a, ErrNoValid
-        break
       }
     }
     return conf, ErrNoValid
@@ -129,6 +102,10 @@ func (conf ConfigArr) Set(path string, value interface{}) ConfigArr {
   return conf.SetPath(strings.Split(path, "."), value)
 }
 
+// SetPath sets the value by the array chain: "+" appends the item,
+// "$" or "*" sets each item and digit sets the item by the index,
+// index equal to the length of the array appends the item.
+// Index greater than the length is ignored.
 func (conf ConfigArr) SetPath(path []string, value interface{}) ConfigArr {
   if len(path) < 1 {
     return nil
@@ -138,58 +115,35 @@ func (conf ConfigArr) SetPath(path []string, value interface{}) ConfigArr {
   path = path[1:]
 
   if "+" == key {
-    if len(path) == 0 {
-      conf = append(conf, prepareValueForSet(value))
-    } else {
-      if isArrayChain(path[0]) {
-        conf = append(conf, make(ConfigArr, 1).SetPath(path, value))
-      } else if "*" == path[0] {
-        // I don't know what me do!
-      } else {
-        conf = append(conf, make(Config).SetPath(path, value))
-      }
-    }
+    conf = append(conf, setItem(nil, path, value))
   } else if "$" == key || "*" == key {
     for i, it := range conf {
-      if len(path) == 0 {
-        conf[i] = prepareValueForSet(value)
-      } else {
-        switch a := it.(type) {
-        case Config:
-          if isArrayChain(path[0]) {
-            conf[i] = append(conf, make(ConfigArr, 1).SetPath(path, value))
-          } else {
-            a.SetPath(path, value)
-          }
-          break
-        case ConfigArr:
-          if isArrayChain(path[0]) {
-            a.SetPath(path, value)
-          } else {
-            conf[i] = append(conf, make(Config).SetPath(path, value)) // Replace value
-          }
-          break
-        default:
-          if isArrayChain(path[0]) {
-            conf[i] = append(conf, make(ConfigArr, 1).SetPath(path, value))
-          } else if "*" == path[0] {
-            // I don't know what me do!
-          } else {
-            conf[i] = append(conf, make(Config).SetPath(path, value))
-          }
-          break
-        }
-      }
+      conf[i] = setItem(it, path, value)
     }
   } else if isDigit(key) { // If digit index
     index, _ := strconv.Atoi(key)
-    if len(conf) > index {
-      conf[index] = prepareValueForSet(value)
+    if index == len(conf) {
+      conf = append(conf, nil)
+    }
+    if index < len(conf) {
+      conf[index] = setItem(conf[index], path, value)
     }
   }
   return conf
 }
 
+// Copy returns deep copy of the array
+func (conf ConfigArr) Copy() ConfigArr {
+  if nil == conf {
+    return nil
+  }
+  c := make(ConfigArr, len(conf))
+  for i, v := range conf {
+    c[i] = copyItem(v)
+  }
+  return c
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 /// Convertion
 ///////////////////////////////////////////////////////////////////////////////
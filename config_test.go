@@ -0,0 +1,348 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+)
+
+func TestSetPathDigitKeys(t *testing.T) {
+  tests := []struct {
+    name   string
+    conf   Config
+    expect Config
+  }{
+    {
+      name:   "not contiguous index",
+      conf:   New().Set("codes.404", "x"),
+      expect: Config{"codes": Config{"404": "x"}},
+    },
+    {
+      name:   "array",
+      conf:   New().Set("list.0", "a").Set("list.1", "b"),
+      expect: Config{"list": ConfigArr{"a", "b"}},
+    },
+    {
+      name:   "index out of the array range",
+      conf:   New().Set("list.0", "a").Set("list.2", "c"),
+      expect: Config{"list": ConfigArr{"a"}},
+    },
+    {
+      name:   "array of objects",
+      conf:   New().Set("list.0.a", 1).Set("list.1.a", 2),
+      expect: Config{"list": ConfigArr{Config{"a": 1}, Config{"a": 2}}},
+    },
+    {
+      name:   "each item of the missing array",
+      conf:   New().Set("list.$.a", 1),
+      expect: Config{},
+    },
+    {
+      name:   "append and set each item",
+      conf:   New().Set("list.+", 1).Set("list.+", 2).Set("list.$", 3),
+      expect: Config{"list": ConfigArr{3, 3}},
+    },
+  }
+
+  for _, test := range tests {
+    if !reflect.DeepEqual(test.expect, test.conf) {
+      t.Errorf("%s: expected %#v, got %#v", test.name, test.expect, test.conf)
+    }
+  }
+}
+
+func TestSetPathSources(t *testing.T) {
+  conf, err := FromData([]byte("errors.404=Not found\n"), "properties")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if v := conf.String("errors.404"); "Not found" != v {
+    t.Errorf("properties: expected %q, got %q", "Not found", v)
+  }
+
+  conf = FromEnviron([]string{"APP_LIST__2=c"}, EnvOptions{Prefix: "APP", Separator: "__"})
+  if v := conf.String("list.2"); "c" != v {
+    t.Errorf("env: expected %q, got %q", "c", v)
+  }
+}
+
+func TestSetPathExistingArray(t *testing.T) {
+  conf, err := FromData([]byte("servers: [a, b]\n"), "yaml")
+  if nil != err {
+    t.Fatal(err)
+  }
+  conf.UpdateEnviron([]string{"APP_SERVERS__5=x", "APP_SERVERS__1=c", "APP_SERVERS__2=d"}, EnvOptions{Prefix: "APP", Separator: "__"})
+  if expect := (ConfigArr{"a", "c", "d"}); !reflect.DeepEqual(expect, conf["servers"]) {
+    t.Errorf("expected %#v, got %#v", expect, conf["servers"])
+  }
+
+  var target struct {
+    Servers []string `config:"servers"`
+  }
+  if err = conf.Decode(&target); nil != err || 3 != len(target.Servers) {
+    t.Errorf("expected 3 servers, got %#v (%v)", target.Servers, err)
+  }
+}
+
+func TestGetPathArrays(t *testing.T) {
+  conf := Config{
+    "list": ConfigArr{"a", Config{"b": "c"}},
+    "app": Config{
+      "x": Config{"p1": "v1"},
+      "y": Config{"p1": "v2"},
+    },
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "list.0", expect: "a"},
+    {path: "list.1.b", expect: "c"},
+    {path: "app.x.p1", expect: "v1"},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+
+  v, err := conf.Get("app.*.p1")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if list, _ := v.([]interface{}); 2 != len(list) {
+    t.Errorf("app.*.p1: expected 2 values, got %#v", v)
+  }
+
+  if _, err = conf.Get("list.5"); nil == err {
+    t.Error("list.5: expected error")
+  }
+}
+
+func TestYAMLKeys(t *testing.T) {
+  tests := []struct {
+    data   string
+    expect Config
+  }{
+    {data: "on: 1\noff: 2", expect: Config{"on": 1, "off": 2}},
+    {data: "y: 1\nyes: 2\nn: 3\nno: 4", expect: Config{"y": 1, "yes": 2, "n": 3, "no": 4}},
+    {data: "cache: {on: 1}", expect: Config{"cache": Config{"on": 1}}},
+  }
+  for _, test := range tests {
+    conf, err := FromData([]byte(test.data), "yaml")
+    if nil != err {
+      t.Errorf("%q: %v", test.data, err)
+      continue
+    }
+    if !reflect.DeepEqual(test.expect, conf) {
+      t.Errorf("%q: expected %#v, got %#v", test.data, test.expect, conf)
+    }
+  }
+
+  conf, _ := FromData([]byte("cache: {on: 1}"), "yaml")
+  if v, err := conf.Get("cache.on"); nil != err || 1 != v {
+    t.Errorf("cache.on: expected 1, got %#v (%v)", v, err)
+  }
+
+  if _, err := FromData([]byte("a: 1\na: 2"), "yaml"); nil == err {
+    t.Error("duplicate key: expected error")
+  }
+}
+
+func TestFromKeys(t *testing.T) {
+  conf, err := From(map[interface{}]interface{}{
+    404:   "missing",
+    true:  "yes",
+    1.5:   "float",
+    2.0:   "integral",
+    "str": "value",
+    "list": []interface{}{
+      map[interface{}]interface{}{1: "a"},
+      []interface{}{map[interface{}]interface{}{false: "b"}},
+    },
+  })
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "404", expect: "missing"},
+    {path: "true", expect: "yes"},
+    {path: "2", expect: "integral"},
+    {path: "str", expect: "value"},
+    {path: "list.0.1", expect: "a"},
+    {path: "list.1.0.false", expect: "b"},
+  }
+  for _, test := range tests {
+    if v, _ := conf.Get(test.path); !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v", test.path, test.expect, v)
+    }
+  }
+  if "float" != conf["1.5"] {
+    t.Errorf("1.5: expected the float key, got %#v", conf["1.5"])
+  }
+  if _, ok := conf["list"].(ConfigArr)[0].(Config); !ok {
+    t.Errorf("list.0: expected Config, got %T", conf["list"].(ConfigArr)[0])
+  }
+}
+
+func TestFromYAMLKeys(t *testing.T) {
+  data := `
+1: a
+true: b
+on: c
+codes:
+  404: missing
+  500: error
+flags:
+  false: off
+  yes: y
+`
+  conf, err := FromData([]byte(data), "yaml")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "1", expect: "a"},
+    {path: "true", expect: "b"},
+    {path: "on", expect: "c"},
+    {path: "codes.404", expect: "missing"},
+    {path: "codes.500", expect: "error"},
+    {path: "flags.false", expect: "off"},
+    {path: "flags.yes", expect: "y"},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+
+  // Keys which are the same after conversion
+  var keyErr *KeyError
+  if _, err = FromData([]byte("1: a\n1.0: b\n"), "yaml"); !errors.As(err, &keyErr) {
+    t.Errorf("duplicate key: expected KeyError, got %v", err)
+  }
+}
+
+func TestFromKeyErrors(t *testing.T) {
+  var keyErr *KeyError
+
+  _, err := From(map[string]interface{}{
+    "a": map[interface{}]interface{}{[2]int{1, 2}: "x"},
+  })
+  if !errors.As(err, &keyErr) {
+    t.Fatalf("unsupported key: expected KeyError, got %v", err)
+  }
+  if "a" != keyErr.Path {
+    t.Errorf("unsupported key: expected path %q, got %q", "a", keyErr.Path)
+  }
+
+  _, err = From(map[string]interface{}{
+    "list": []interface{}{map[interface{}]interface{}{1: "a", "1": "b"}},
+  })
+  if !errors.As(err, &keyErr) {
+    t.Fatalf("duplicate key: expected KeyError, got %v", err)
+  }
+  if "list.0" != keyErr.Path || 1 != keyErr.Key {
+    t.Errorf("duplicate key: expected 1 in %q, got %#v in %q", "list.0", keyErr.Key, keyErr.Path)
+  }
+
+  if _, err = From(map[interface{}]interface{}{nil: "x"}); !errors.As(err, &keyErr) {
+    t.Errorf("null key: expected KeyError, got %v", err)
+  }
+}
+
+func TestFromDataMulti(t *testing.T) {
+  data := "env: dev\nport: 1\n---\n---\nenv: prod\nport: 2\n"
+  list, err := FromDataMulti([]byte(data), "yaml")
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := []Config{
+    {"env": "dev", "port": 1},
+    {},
+    {"env": "prod", "port": 2},
+  }
+  if !reflect.DeepEqual(expect, list) {
+    t.Errorf("expected %#v, got %#v", expect, list)
+  }
+
+  if _, err = FromDataMulti([]byte("a: 1\n---\n- x\n"), "yaml"); nil == err {
+    t.Error("sequence document: expected error")
+  }
+}
+
+func TestYAMLAnchors(t *testing.T) {
+  data := `
+base: &base
+  host: localhost
+  port: 80
+  tags: [a, b]
+dev:
+  <<: *base
+  port: 8080
+both:
+  <<: [*base, {port: 1, debug: true}]
+copy: *base
+`
+  conf, err := FromData([]byte(data), "yaml")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "dev.host", expect: "localhost"},
+    {path: "dev.port", expect: 8080},
+    {path: "both.port", expect: 80},
+    {path: "both.debug", expect: true},
+    {path: "copy.tags.1", expect: "b"},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+
+  // Aliases are independent copies
+  conf.Set("copy.host", "changed")
+  conf.Set("copy.tags.0", "changed")
+  conf.Set("dev.tags.1", "changed")
+  if "localhost" != conf.String("base.host") || "a" != conf.String("base.tags.0") || "b" != conf.String("base.tags.1") {
+    t.Errorf("anchor is changed by the alias: %#v", conf["base"])
+  }
+}
@@ -0,0 +1,432 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "encoding"
+  "encoding/json"
+  "fmt"
+  "math"
+  "reflect"
+  "strconv"
+  "strings"
+  "time"
+)
+
+var (
+  textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+  jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+  durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+type decoder struct {
+  errs DecodeErrors
+}
+
+// Decode fills the structure, map or slice by the config values.
+// Fields are matched by the `config:"name"` tag or by the field name ignoring
+// the case, embedded structs are inline. Missing values are set by the `default`
+// tag or keep the field values. All values which can't be decoded
+// are returned as DecodeErrors.
+func (conf Config) Decode(out interface{}) error {
+  return decodeValue("", conf, out)
+}
+
+// DecodePath fills the target by the config value of the path
+func (conf Config) DecodePath(path string, out interface{}) error {
+  value, err := conf.Get(path)
+  if nil != err {
+    return err
+  }
+  return decodeValue(path, value, out)
+}
+
+func decodeValue(path string, value, out interface{}) error {
+  v := reflect.ValueOf(out)
+  if reflect.Ptr != v.Kind() || v.IsNil() {
+    return ErrInvalidTarget
+  }
+
+  d := &decoder{}
+  d.decode(path, value, v.Elem())
+  if len(d.errs) > 0 {
+    return d.errs
+  }
+  return nil
+}
+
+func (d *decoder) errorf(path string, value interface{}, format string, args ...interface{}) {
+  d.errs = append(d.errs, &DecodeError{Path: path, Value: value, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (d *decoder) decode(path string, value interface{}, v reflect.Value) {
+  if nil == value {
+    return // Keep the current value
+  }
+
+  t := v.Type()
+  if reflect.Ptr == t.Kind() {
+    if v.IsNil() {
+      v.Set(reflect.New(t.Elem()))
+    }
+    d.decode(path, value, v.Elem())
+    return
+  }
+
+  // Value of the same type, e.g. time.Time of YAML or TOML
+  if reflect.Interface == t.Kind() || reflect.TypeOf(value) == t {
+    if rv := reflect.ValueOf(value); rv.Type().AssignableTo(t) {
+      v.Set(rv)
+      return
+    }
+  }
+
+  if v.CanAddr() {
+    switch {
+    case isScalar(value) && reflect.PtrTo(t).Implements(textUnmarshalerType):
+      if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(scalarToString(value))); nil != err {
+        d.errorf(path, value, "%v", err)
+      }
+      return
+    case reflect.PtrTo(t).Implements(jsonUnmarshalerType):
+      data, err := json.Marshal(value)
+      if nil == err {
+        err = v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data)
+      }
+      if nil != err {
+        d.errorf(path, value, "%v", err)
+      }
+      return
+    }
+  }
+
+  switch t.Kind() {
+  case reflect.Struct:
+    d.decodeStruct(path, value, v)
+    break
+  case reflect.Map:
+    d.decodeMap(path, value, v)
+    break
+  case reflect.Slice:
+    d.decodeSlice(path, value, v)
+    break
+  case reflect.Array:
+    d.decodeArray(path, value, v)
+    break
+  case reflect.String:
+    if !isScalar(value) {
+      d.errorf(path, value, "expected string")
+    } else {
+      v.SetString(scalarToString(value))
+    }
+    break
+  case reflect.Bool:
+    d.decodeBool(path, value, v)
+    break
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    d.decodeInt(path, value, v)
+    break
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+    d.decodeUint(path, value, v)
+    break
+  case reflect.Float32, reflect.Float64:
+    d.decodeFloat(path, value, v)
+    break
+  default:
+    d.errorf(path, value, "unsupported type %s", t)
+    break
+  }
+}
+
+func (d *decoder) decodeStruct(path string, value interface{}, v reflect.Value) {
+  conf, ok := value.(Config)
+  if !ok {
+    d.errorf(path, value, "expected object for %s", v.Type())
+    return
+  }
+
+  for _, f := range structFields(v.Type()) {
+    fv := v.Field(f.index)
+    if f.inline {
+      if reflect.Ptr == fv.Kind() {
+        if !fv.CanSet() {
+          continue // Unexported embedded pointer
+        }
+        if fv.IsNil() {
+          fv.Set(reflect.New(fv.Type().Elem()))
+        }
+        fv = fv.Elem()
+      }
+      d.decodeStruct(path, conf, fv)
+      continue
+    }
+    if !fv.CanSet() {
+      continue
+    }
+    if key, ok := lookupKey(conf, f); ok {
+      d.decode(joinPath(path, key), conf[key], fv)
+    } else if f.hasDef {
+      d.decode(joinPath(path, f.name), parseDefault(f.def, fv.Type()), fv)
+    } else if reflect.Struct == fv.Kind() && !hasUnmarshaler(fv.Type()) {
+      d.decodeStruct(joinPath(path, f.name), Config{}, fv) // Defaults of the nested struct
+    }
+  }
+}
+
+func hasUnmarshaler(t reflect.Type) bool {
+  pt := reflect.PtrTo(t)
+  return pt.Implements(textUnmarshalerType) || pt.Implements(jsonUnmarshalerType)
+}
+
+// lookupKey returns the config key of the field by the tag name
+// or by the field name ignoring the case
+func lookupKey(conf Config, f structField) (string, bool) {
+  if _, ok := conf[f.name]; ok || f.tagged {
+    return f.name, ok
+  }
+  for _, key := range sortedKeys(conf) {
+    if strings.EqualFold(key, f.name) {
+      return key, true
+    }
+  }
+  return "", false
+}
+
+func (d *decoder) decodeMap(path string, value interface{}, v reflect.Value) {
+  conf, ok := value.(Config)
+  if !ok {
+    d.errorf(path, value, "expected object for %s", v.Type())
+    return
+  }
+
+  t := v.Type()
+  if v.IsNil() {
+    v.Set(reflect.MakeMapWithSize(t, len(conf)))
+  }
+  for _, key := range sortedKeys(conf) {
+    kv := reflect.New(t.Key()).Elem()
+    if !d.decodeKey(joinPath(path, key), key, kv) {
+      continue
+    }
+
+    // Update the existing item, e.g. the struct with the current values
+    ev := reflect.New(t.Elem()).Elem()
+    if cur := v.MapIndex(kv); cur.IsValid() {
+      ev.Set(cur)
+    }
+    d.decode(joinPath(path, key), conf[key], ev)
+    v.SetMapIndex(kv, ev)
+  }
+}
+
+func (d *decoder) decodeKey(path, key string, kv reflect.Value) bool {
+  errs := len(d.errs)
+  switch kv.Kind() {
+  case reflect.String:
+    if reflect.PtrTo(kv.Type()).Implements(textUnmarshalerType) {
+      d.decode(path, key, kv)
+    } else {
+      kv.SetString(key)
+    }
+    break
+  default:
+    d.decode(path, key, kv)
+    break
+  }
+  return errs == len(d.errs)
+}
+
+func (d *decoder) decodeSlice(path string, value interface{}, v reflect.Value) {
+  arr, ok := value.(ConfigArr)
+  if !ok {
+    if s, isString := value.(string); isString && reflect.Uint8 == v.Type().Elem().Kind() {
+      v.SetBytes([]byte(s))
+      return
+    }
+    d.errorf(path, value, "expected array for %s", v.Type())
+    return
+  }
+
+  slice := reflect.MakeSlice(v.Type(), len(arr), len(arr))
+  for i, it := range arr {
+    d.decode(joinPath(path, strconv.Itoa(i)), it, slice.Index(i))
+  }
+  v.Set(slice)
+}
+
+func (d *decoder) decodeArray(path string, value interface{}, v reflect.Value) {
+  arr, ok := value.(ConfigArr)
+  if !ok {
+    d.errorf(path, value, "expected array for %s", v.Type())
+    return
+  }
+  if len(arr) != v.Len() {
+    d.errorf(path, value, "expected %d items", v.Len())
+    return
+  }
+  for i, it := range arr {
+    d.decode(joinPath(path, strconv.Itoa(i)), it, v.Index(i))
+  }
+}
+
+func (d *decoder) decodeBool(path string, value interface{}, v reflect.Value) {
+  switch b := value.(type) {
+  case bool:
+    v.SetBool(b)
+    return
+  case string:
+    if r, err := strconv.ParseBool(strings.TrimSpace(b)); nil == err {
+      v.SetBool(r)
+      return
+    }
+  }
+  d.errorf(path, value, "expected bool")
+}
+
+func (d *decoder) decodeInt(path string, value interface{}, v reflect.Value) {
+  if s, ok := value.(string); ok && durationType == v.Type() {
+    if r, err := time.ParseDuration(strings.TrimSpace(s)); nil == err {
+      v.SetInt(int64(r))
+    } else {
+      d.errorf(path, value, "expected duration")
+    }
+    return
+  }
+
+  r, ok := toInt64(value)
+  if !ok {
+    d.errorf(path, value, "expected integer")
+    return
+  }
+  if v.OverflowInt(r) {
+    d.errorf(path, value, "value overflows %s", v.Type())
+    return
+  }
+  v.SetInt(r)
+}
+
+func (d *decoder) decodeUint(path string, value interface{}, v reflect.Value) {
+  var r uint64
+  switch n := value.(type) {
+  case uint:
+    r = uint64(n)
+    break
+  case uint64:
+    r = n
+    break
+  default:
+    i, ok := toInt64(value)
+    if !ok {
+      d.errorf(path, value, "expected integer")
+      return
+    }
+    if i < 0 {
+      d.errorf(path, value, "expected non negative integer")
+      return
+    }
+    r = uint64(i)
+    break
+  }
+  if v.OverflowUint(r) {
+    d.errorf(path, value, "value overflows %s", v.Type())
+    return
+  }
+  v.SetUint(r)
+}
+
+func (d *decoder) decodeFloat(path string, value interface{}, v reflect.Value) {
+  var r float64
+  switch n := value.(type) {
+  case float64:
+    r = n
+    break
+  case float32:
+    r = float64(n)
+    break
+  case string:
+    f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+    if nil != err {
+      d.errorf(path, value, "expected number")
+      return
+    }
+    r = f
+    break
+  default:
+    i, ok := toInt64(value)
+    if !ok {
+      d.errorf(path, value, "expected number")
+      return
+    }
+    r = float64(i)
+    break
+  }
+  if v.OverflowFloat(r) {
+    d.errorf(path, value, "value overflows %s", v.Type())
+    return
+  }
+  v.SetFloat(r)
+}
+
+// toInt64 converts integer, integral float or numeric string
+func toInt64(value interface{}) (int64, bool) {
+  switch n := value.(type) {
+  case int:
+    return int64(n), true
+  case int8:
+    return int64(n), true
+  case int16:
+    return int64(n), true
+  case int32:
+    return int64(n), true
+  case int64:
+    return n, true
+  case uint:
+    return int64(n), n <= math.MaxInt64
+  case uint8:
+    return int64(n), true
+  case uint16:
+    return int64(n), true
+  case uint32:
+    return int64(n), true
+  case uint64:
+    return int64(n), n <= math.MaxInt64
+  case float32:
+    return int64(n), isIntegral(float64(n))
+  case float64:
+    return int64(n), isIntegral(n)
+  case string:
+    r, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+    return r, nil == err
+  }
+  return 0, false
+}
+
+func isScalar(value interface{}) bool {
+  switch value.(type) {
+  case Config, ConfigArr, map[string]interface{}, []interface{}:
+    return false
+  }
+  return true
+}
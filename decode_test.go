@@ -0,0 +1,237 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "fmt"
+  "reflect"
+  "strings"
+  "testing"
+  "time"
+)
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+  switch strings.ToLower(string(text)) {
+  case "debug":
+    *l = 1
+    break
+  case "info":
+    *l = 2
+    break
+  default:
+    return fmt.Errorf("unknown level %q", text)
+  }
+  return nil
+}
+
+type testDecodeDB struct {
+  Host    string
+  Port    int
+  Timeout time.Duration
+}
+
+type testDecodeBase struct {
+  Name  string
+  Debug bool
+}
+
+type testDecodeServer struct {
+  Host   string
+  Weight float64
+}
+
+type testDecodeConfig struct {
+  testDecodeBase
+  *testDecodeExtra
+
+  DB      testDecodeDB
+  Replica *testDecodeDB
+  Level   testLevel `config:"log_level"`
+  Servers []testDecodeServer
+  Tags    []string
+  Ports   [2]uint16
+  Limits  map[string]int
+  Zones   map[string]*testDecodeServer
+  Any     interface{}
+  Skipped string `config:"-"`
+}
+
+type testDecodeExtra struct {
+  Extra string
+}
+
+func TestDecode(t *testing.T) {
+  conf := Config{
+    "name":  "app",
+    "DEBUG": "true",
+    "extra": "inline pointer",
+    "db": Config{
+      "host":    "localhost",
+      "port":    "5432",
+      "timeout": "1m30s",
+    },
+    "replica":   Config{"host": "replica", "port": 5433.0, "timeout": int64(time.Second)},
+    "log_level": "INFO",
+    "servers": ConfigArr{
+      Config{"host": "a", "weight": "0.5"},
+      Config{"host": "b", "weight": 2},
+    },
+    "tags":    ConfigArr{"x", 1, true},
+    "ports":   ConfigArr{"80", 443},
+    "limits":  Config{"a": "1", "b": 2.0},
+    "zones":   Config{"eu": Config{"host": "eu", "weight": 1}},
+    "any":     ConfigArr{Config{"a": 1}},
+    "skipped": "value",
+  }
+
+  var target testDecodeConfig
+  if err := conf.Decode(&target); nil != err {
+    t.Fatal(err)
+  }
+
+  // Unexported embedded pointer can't be allocated, so it's skipped
+  expect := testDecodeConfig{
+    testDecodeBase:  testDecodeBase{Name: "app", Debug: true},
+    DB:              testDecodeDB{Host: "localhost", Port: 5432, Timeout: 90 * time.Second},
+    Replica:         &testDecodeDB{Host: "replica", Port: 5433, Timeout: time.Second},
+    Level:           2,
+    Servers:         []testDecodeServer{{Host: "a", Weight: 0.5}, {Host: "b", Weight: 2}},
+    Tags:            []string{"x", "1", "true"},
+    Ports:           [2]uint16{80, 443},
+    Limits:          map[string]int{"a": 1, "b": 2},
+    Zones:           map[string]*testDecodeServer{"eu": {Host: "eu", Weight: 1}},
+    Any:             ConfigArr{Config{"a": 1}},
+  }
+  if !reflect.DeepEqual(expect, target) {
+    t.Errorf("expected %+v, got %+v", expect, target)
+  }
+}
+
+func TestDecodeKeepValues(t *testing.T) {
+  target := testDecodeConfig{
+    DB:     testDecodeDB{Host: "default", Port: 1},
+    Limits: map[string]int{"a": 1},
+  }
+  conf := Config{"db": Config{"port": 2}, "limits": Config{"b": 2}, "name": nil}
+  if err := conf.Decode(&target); nil != err {
+    t.Fatal(err)
+  }
+  if "default" != target.DB.Host || 2 != target.DB.Port {
+    t.Errorf("expected the struct to be updated, got %+v", target.DB)
+  }
+  if !reflect.DeepEqual(map[string]int{"a": 1, "b": 2}, target.Limits) {
+    t.Errorf("expected the map to be updated, got %+v", target.Limits)
+  }
+}
+
+func TestDecodePath(t *testing.T) {
+  conf := Config{"db": Config{"host": "localhost", "port": 5432}}
+
+  var db testDecodeDB
+  if err := conf.DecodePath("db", &db); nil != err {
+    t.Fatal(err)
+  }
+  if (testDecodeDB{Host: "localhost", Port: 5432}) != db {
+    t.Errorf("unexpected value %+v", db)
+  }
+
+  var port int
+  err := conf.DecodePath("db.host", &port)
+  var errs DecodeErrors
+  if !errors.As(err, &errs) || 1 != len(errs) || "db.host" != errs[0].Path {
+    t.Errorf("expected the error with the path db.host, got %v", err)
+  }
+
+  if err := conf.DecodePath("db.missing", &port); nil == err {
+    t.Error("expected error for the missing path")
+  }
+}
+
+func TestDecodeInvalidTarget(t *testing.T) {
+  var target testDecodeDB
+  for _, out := range []interface{}{target, nil, (*testDecodeDB)(nil)} {
+    if err := (Config{}).Decode(out); ErrInvalidTarget != err {
+      t.Errorf("%#v: expected ErrInvalidTarget, got %v", out, err)
+    }
+  }
+}
+
+func TestDecodeErrors(t *testing.T) {
+  conf := Config{
+    "debug": "maybe",
+    "db": Config{
+      "host":    Config{"nested": true},
+      "port":    "port",
+      "timeout": "soon",
+    },
+    "replica":   "replica",
+    "log_level": "trace",
+    "servers": ConfigArr{
+      Config{"host": "a", "weight": "heavy"},
+    },
+    "ports":  ConfigArr{-1, 70000},
+    "limits": Config{"a": 1.5, "b": 2},
+    "zones":  ConfigArr{},
+  }
+
+  var target testDecodeConfig
+  err := conf.Decode(&target)
+  var errs DecodeErrors
+  if !errors.As(err, &errs) {
+    t.Fatalf("expected DecodeErrors, got %v", err)
+  }
+
+  expect := []string{
+    "debug",
+    "db.host",
+    "db.port",
+    "db.timeout",
+    "replica",
+    "log_level",
+    "servers.0.weight",
+    "ports.0",
+    "ports.1",
+    "limits.a",
+    "zones",
+  }
+  paths := make([]string, 0, len(errs))
+  for _, e := range errs {
+    paths = append(paths, e.Path)
+  }
+  if !reflect.DeepEqual(expect, paths) {
+    t.Errorf("expected errors of %v, got %v", expect, paths)
+  }
+
+  // Valid values are decoded in spite of the errors
+  if 2 != target.Limits["b"] || "a" != target.Servers[0].Host {
+    t.Errorf("expected valid values to be decoded, got %+v", target)
+  }
+  if msg := err.Error(); !strings.Contains(msg, `db.port: expected integer (value "port")`) {
+    t.Errorf("unexpected message %s", msg)
+  }
+}
@@ -0,0 +1,118 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "gopkg.in/yaml.v3"
+  "reflect"
+  "strings"
+)
+
+// Defaults returns the config of the `default` tag values of the struct fields,
+// e.g. `default:"8080"` or `default:"[a, b]"`. It's suitable for the lowest layer
+// of the Stack. Fields of the nested structs are included, pointers are skipped.
+// Invalid defaults are returned as DecodeErrors.
+func Defaults(v interface{}) (Config, error) {
+  t := reflect.TypeOf(v)
+  if nil == t || reflect.Struct != indirectType(t).Kind() {
+    return nil, ErrUnsupportedValue
+  }
+
+  d := &decoder{}
+  conf := d.defaults("", indirectType(t))
+  if len(d.errs) > 0 {
+    return nil, d.errs
+  }
+  return conf, nil
+}
+
+func (d *decoder) defaults(path string, t reflect.Type) Config {
+  conf := make(Config)
+  for _, f := range structFields(t) {
+    ft := f.field.Type
+    if f.inline {
+      for key, it := range d.defaults(path, indirectType(ft)) {
+        if _, ok := conf[key]; !ok { // Fields of the parent have priority
+          conf[key] = it
+        }
+      }
+      continue
+    }
+
+    fpath := joinPath(path, f.name)
+    switch {
+    case f.hasDef:
+      // Decode the default into the field type to get the typed value
+      v := reflect.New(ft).Elem()
+      errs := len(d.errs)
+      if d.decode(fpath, parseDefault(f.def, ft), v); errs == len(d.errs) {
+        it, err := fromValue(v, fpath)
+        if nil != err {
+          d.errorf(fpath, f.def, "%v", err)
+        } else {
+          conf[f.name] = it
+        }
+      }
+      break
+    case reflect.Struct == ft.Kind() && !hasUnmarshaler(ft):
+      if sub := d.defaults(fpath, ft); len(sub) > 0 {
+        conf[f.name] = sub
+      }
+      break
+    }
+  }
+  return conf
+}
+
+// parseDefault returns the tag value, defaults of the lists and objects
+// are parsed as YAML flow values, e.g. [a, b] or {host: localhost}
+func parseDefault(def string, t reflect.Type) interface{} {
+  if !isFlowDefaultType(t) {
+    return def
+  }
+  if s := strings.TrimSpace(def); strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+    var info interface{}
+    if nil == yaml.Unmarshal([]byte(s), &info) {
+      if it, err := fromItem(info, ""); nil == err {
+        return it
+      }
+    }
+  }
+  return def
+}
+
+// isFlowDefaultType returns true if the default of the type can be
+// the flow value, so the string default like "[INFO]" is kept as is
+func isFlowDefaultType(t reflect.Type) bool {
+  t = indirectType(t)
+  if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+    return false
+  }
+  switch t.Kind() {
+  case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+    return true
+  }
+  return false
+}
@@ -0,0 +1,139 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+  "time"
+)
+
+type testDefaultsBase struct {
+  Name string `config:"name" default:"base"`
+  Port int    `config:"port" default:"1"`
+}
+
+type testDefaultsDB struct {
+  Host    string        `config:"host" default:"localhost"`
+  Timeout time.Duration `config:"timeout" default:"5s"`
+  NoDef   string        `config:"no_default"`
+}
+
+type testDefaultsConfig struct {
+  testDefaultsBase
+  Port    int               `config:"port" default:"8080"` // Has priority over the inline one
+  Prefix  string            `config:"prefix" default:"[INFO]"`
+  Format  string            `config:"format" default:"{level} {msg}"`
+  Hosts   []string          `config:"hosts" default:"[a, b]"`
+  Ports   [2]int            `config:"ports" default:"[80, 443]"`
+  Labels  map[string]string `config:"labels" default:"{env: dev}"`
+  DB      testDefaultsDB    `config:"db"`
+  Replica *testDefaultsDB   `config:"replica"`
+  Level   testLevel         `config:"level" default:"debug"`
+}
+
+func TestDefaults(t *testing.T) {
+  expect := Config{
+    "name":   "base",
+    "port":   8080,
+    "prefix": "[INFO]",
+    "format": "{level} {msg}",
+    "hosts":  ConfigArr{"a", "b"},
+    "ports":  ConfigArr{80, 443},
+    "labels": Config{"env": "dev"},
+    "db":     Config{"host": "localhost", "timeout": "5s"},
+    "level":  int64(1),
+  }
+  for _, v := range []interface{}{testDefaultsConfig{}, &testDefaultsConfig{}} {
+    conf, err := Defaults(v)
+    if nil != err {
+      t.Fatal(err)
+    }
+    if !reflect.DeepEqual(expect, conf) {
+      t.Errorf("expected %#v, got %#v", expect, conf)
+    }
+  }
+
+  for _, v := range []interface{}{nil, 1, "struct", []testDefaultsConfig{}} {
+    if _, err := Defaults(v); ErrUnsupportedValue != err {
+      t.Errorf("%#v: expected ErrUnsupportedValue, got %v", v, err)
+    }
+  }
+}
+
+func TestDecodeDefaults(t *testing.T) {
+  var target testDefaultsConfig
+  if err := (Config{"db": Config{"host": "db"}}).Decode(&target); nil != err {
+    t.Fatal(err)
+  }
+  expect := testDefaultsConfig{
+    testDefaultsBase: testDefaultsBase{Name: "base", Port: 1},
+    Port:             8080,
+    Prefix:           "[INFO]",
+    Format:           "{level} {msg}",
+    Hosts:            []string{"a", "b"},
+    Ports:            [2]int{80, 443},
+    Labels:           map[string]string{"env": "dev"},
+    DB:               testDefaultsDB{Host: "db", Timeout: 5 * time.Second},
+    Level:            1,
+  }
+  if !reflect.DeepEqual(expect, target) {
+    t.Errorf("expected %+v, got %+v", expect, target)
+  }
+}
+
+func TestDefaultsErrors(t *testing.T) {
+  type invalidDB struct {
+    Port int `config:"port" default:"port"`
+  }
+  type invalid struct {
+    Count   int           `config:"count" default:"many"`
+    Hosts   []string      `config:"hosts" default:"[a, [b"`
+    Ports   [2]int        `config:"ports" default:"[80]"`
+    Level   testLevel     `config:"level" default:"trace"`
+    Timeout time.Duration `config:"timeout" default:"soon"`
+    DB      invalidDB     `config:"db"`
+  }
+
+  _, err := Defaults(invalid{})
+  var errs DecodeErrors
+  if !errors.As(err, &errs) {
+    t.Fatalf("expected DecodeErrors, got %v", err)
+  }
+  paths := make([]string, 0, len(errs))
+  for _, e := range errs {
+    paths = append(paths, e.Path)
+  }
+  expect := []string{"count", "hosts", "ports", "level", "timeout", "db.port"}
+  if !reflect.DeepEqual(expect, paths) {
+    t.Errorf("expected errors of %v, got %v", expect, paths)
+  }
+
+  var target invalid
+  if err := (Config{}).Decode(&target); !errors.As(err, &errs) || len(expect) != len(errs) {
+    t.Errorf("expected %d decode errors, got %v", len(expect), err)
+  }
+}
@@ -0,0 +1,73 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "fmt"
+  "io/fs"
+)
+
+// FromDir loads files of the directory matching the pattern (e.g. "*.yaml")
+// in the lexical order and merges them by Update. Empty pattern means
+// all files of the registered formats. The format of each file is selected
+// by the extension, so the formats may be mixed.
+func FromDir(dir, pattern string) (Config, error) {
+  conf, _, err := newFileLoader().loadDir(dir, pattern)
+  return conf, err
+}
+
+// FromDirFS loads files of the directory of the file system like FromDir
+func FromDirFS(fsys fs.FS, dir, pattern string) (Config, error) {
+  conf, _, err := newFSLoader(fsys).loadDir(dir, pattern)
+  return conf, err
+}
+
+// LoadDir adds or replaces the layer loaded by FromDir,
+// origins of the values have the file names
+func (s *Stack) LoadDir(name string, priority int, dir, pattern string) error {
+  return s.load(name, priority, func() (Config, Origins, error) {
+    return newFileLoader().loadDir(dir, pattern)
+  })
+}
+
+func (l *fileLoader) loadDir(dir, pattern string) (Config, Origins, error) {
+  files, err := l.files(dir, pattern)
+  if nil != err {
+    return nil, nil, err
+  }
+
+  var (
+    conf    = make(Config)
+    origins = make(Origins)
+  )
+  for _, filename := range files {
+    c, list, err := l.load(filename, "")
+    if nil != err {
+      return nil, nil, fmt.Errorf("%s: %w", filename, err)
+    }
+    mergeOrigins(conf, origins, c, list)
+  }
+  return conf, origins, nil
+}
@@ -0,0 +1,115 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+  "testing/fstest"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+  t.Helper()
+  for name, data := range files {
+    filename := filepath.Join(dir, name)
+    if err := os.MkdirAll(filepath.Dir(filename), 0755); nil != err {
+      t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(filename, []byte(data), 0644); nil != err {
+      t.Fatal(err)
+    }
+  }
+}
+
+func TestFromDir(t *testing.T) {
+  dir := t.TempDir()
+  writeTestFiles(t, dir, map[string]string{
+    "10-base.yaml":      "db:\n  host: base\n  port: 5432\nname: app\n",
+    "20-db.toml":        "[db]\nhost = \"toml\"\n",
+    "30-local.json":     `{"db": {"host": "local"}, "debug": true}`,
+    "README.md":         "not a config",
+    "40-dir.yaml/a.yml": "skipped: true\n",
+  })
+
+  conf, err := FromDir(dir, "")
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{
+    "db":    Config{"host": "local", "port": 5432},
+    "name":  "app",
+    "debug": true,
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+
+  if conf, err = FromDir(dir, "*.toml"); nil != err || "toml" != conf.String("db.host") || 1 != len(conf) {
+    t.Errorf("pattern: expected toml file only, got %#v (%v)", conf, err)
+  }
+}
+
+func TestFromDirErrors(t *testing.T) {
+  dir := t.TempDir()
+  writeTestFiles(t, dir, map[string]string{
+    "10-ok.yaml":     "a: 1\n",
+    "20-broken.toml": "a = 1\nb = \n",
+  })
+
+  _, err := FromDir(dir, "")
+  var perr *ParseError
+  if !errors.As(err, &perr) || 2 != perr.Line {
+    t.Fatalf("expected wrapped ParseError at line 2, got %v", err)
+  }
+  if !strings.Contains(err.Error(), "20-broken.toml") {
+    t.Errorf("expected the file name in %q", err)
+  }
+
+  if _, err = FromDir(filepath.Join(dir, "10-ok.yaml"), ""); nil == err {
+    t.Error("file: expected not a directory error")
+  }
+  if _, err = FromDir(filepath.Join(dir, "missing"), ""); !errors.Is(err, os.ErrNotExist) {
+    t.Errorf("missing: expected ErrNotExist, got %v", err)
+  }
+}
+
+func TestFromDirFS(t *testing.T) {
+  fsys := fstest.MapFS{
+    "conf.d/b.yaml": {Data: []byte("a: 2\n")},
+    "conf.d/a.yaml": {Data: []byte("a: 1\nb: 1\n")},
+  }
+  conf, err := FromDirFS(fsys, "conf.d", "")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"a": 2, "b": 1}); !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
@@ -0,0 +1,175 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "encoding"
+  "encoding/json"
+  "fmt"
+  "reflect"
+  "time"
+)
+
+var (
+  textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+  jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+  timeType          = reflect.TypeOf(time.Time{})
+)
+
+// fromStruct converts the struct into the Config by the `config:"name,omitempty,inline"`
+// tags, so the result is decoded back by Config.Decode
+func fromStruct(v reflect.Value, path string) (Config, error) {
+  conf := make(Config)
+  for _, f := range structFields(v.Type()) {
+    fv := v.Field(f.index)
+    if f.inline {
+      if reflect.Ptr == fv.Kind() {
+        if fv.IsNil() {
+          continue
+        }
+        fv = fv.Elem()
+      }
+      sub, err := fromStruct(fv, path)
+      if nil != err {
+        return nil, err
+      }
+      for key, it := range sub {
+        if _, ok := conf[key]; !ok { // Fields of the parent have priority
+          conf[key] = it
+        }
+      }
+      continue
+    }
+
+    if !fv.CanInterface() || (f.omitEmpty && isEmptyValue(fv)) {
+      continue
+    }
+    it, err := fromValue(fv, joinPath(path, f.name))
+    if nil != err {
+      return nil, err
+    }
+    conf[f.name] = it
+  }
+  return conf, nil
+}
+
+// fromValue converts the value into the config item, values of the named
+// types are converted into the basic types, marshalers are used
+// for the custom types
+func fromValue(v reflect.Value, path string) (interface{}, error) {
+  if !v.IsValid() {
+    return nil, nil
+  }
+
+  switch v.Kind() {
+  case reflect.Ptr, reflect.Interface:
+    if v.IsNil() {
+      return nil, nil
+    }
+    break
+  }
+
+  if timeType != v.Type() {
+    if m, ok := marshalerOf(v, textMarshalerType); ok {
+      text, err := m.(encoding.TextMarshaler).MarshalText()
+      if nil != err {
+        return nil, fmt.Errorf("%s: %w", path, err)
+      }
+      return string(text), nil
+    }
+    if m, ok := marshalerOf(v, jsonMarshalerType); ok {
+      var info interface{}
+      data, err := m.(json.Marshaler).MarshalJSON()
+      if nil == err {
+        err = json.Unmarshal(data, &info)
+      }
+      if nil != err {
+        return nil, fmt.Errorf("%s: %w", path, err)
+      }
+      return fromItem(info, path)
+    }
+  }
+
+  switch v.Kind() {
+  case reflect.Ptr, reflect.Interface:
+    return fromValue(v.Elem(), path)
+  case reflect.Map:
+    return fromMap(v.Interface(), path)
+  case reflect.Slice, reflect.Array:
+    return fromSlice(v.Interface(), path)
+  case reflect.Struct:
+    if timeType == v.Type() {
+      return v.Interface(), nil
+    }
+    return fromStruct(v, path)
+  case reflect.String:
+    return v.String(), nil
+  case reflect.Bool:
+    return v.Bool(), nil
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    if durationType == v.Type() {
+      return time.Duration(v.Int()).String(), nil
+    }
+    if "" == v.Type().PkgPath() {
+      return v.Interface(), nil
+    }
+    return v.Int(), nil
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+    if "" == v.Type().PkgPath() {
+      return v.Interface(), nil
+    }
+    return v.Uint(), nil
+  case reflect.Float32, reflect.Float64:
+    if "" == v.Type().PkgPath() {
+      return v.Interface(), nil
+    }
+    return v.Float(), nil
+  }
+  return v.Interface(), nil
+}
+
+// marshalerOf returns the value or the pointer to it which implements the interface
+func marshalerOf(v reflect.Value, iface reflect.Type) (interface{}, bool) {
+  if !v.CanInterface() {
+    return nil, false
+  }
+  if v.Type().Implements(iface) {
+    return v.Interface(), true
+  }
+  if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(iface) {
+    return v.Addr().Interface(), true
+  }
+  return nil, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+  switch v.Kind() {
+  case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+    return 0 == v.Len()
+  case reflect.Ptr, reflect.Interface:
+    return v.IsNil()
+  }
+  return v.IsZero()
+}
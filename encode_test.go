@@ -0,0 +1,222 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "encoding/json"
+  "fmt"
+  "reflect"
+  "strings"
+  "testing"
+  "time"
+)
+
+type testPort uint16
+
+type testRatio float32
+
+type testColor struct {
+  R, G, B uint8
+}
+
+func (c testColor) MarshalText() ([]byte, error) {
+  return []byte(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), nil
+}
+
+func (c *testColor) UnmarshalText(text []byte) error {
+  _, err := fmt.Sscanf(string(text), "#%02x%02x%02x", &c.R, &c.G, &c.B)
+  return err
+}
+
+type testRange struct {
+  Min, Max int
+}
+
+func (r testRange) MarshalJSON() ([]byte, error) {
+  return json.Marshal([]int{r.Min, r.Max})
+}
+
+func (r *testRange) UnmarshalJSON(data []byte) error {
+  var arr []int
+  if err := json.Unmarshal(data, &arr); nil != err {
+    return err
+  }
+  if 2 != len(arr) {
+    return fmt.Errorf("expected two values")
+  }
+  r.Min, r.Max = arr[0], arr[1]
+  return nil
+}
+
+type testEncodeBase struct {
+  Name string   `config:"name"`
+  Port testPort `config:"port"`
+}
+
+type testEncodeConfig struct {
+  testEncodeBase
+  Extra   *testEncodeBase `config:"extra,inline"`
+  Port    testPort        `config:"port"` // Has priority over the inline one
+  Ratio   testRatio       `config:"ratio"`
+  Count   int32           `config:"count"`
+  Color   testColor       `config:"color"`
+  Range   testRange       `config:"range"`
+  Timeout time.Duration   `config:"timeout"`
+  Started time.Time       `config:"started"`
+  Tags    []string        `config:"tags,omitempty"`
+  Labels  map[string]int  `config:"labels,omitempty"`
+  Note    string          `config:"note,omitempty"`
+  Child   *testEncodeBase `config:"child,omitempty"`
+  Skipped string          `config:"-"`
+}
+
+func TestFromStruct(t *testing.T) {
+  started := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+  src := testEncodeConfig{
+    testEncodeBase: testEncodeBase{Name: "app", Port: 1},
+    Extra:          &testEncodeBase{Name: "extra", Port: 2},
+    Port:           8080,
+    Ratio:          0.5,
+    Count:          3,
+    Color:          testColor{R: 255, G: 128},
+    Range:          testRange{Min: 1, Max: 10},
+    Timeout:        90 * time.Second,
+    Started:        started,
+    Labels:         map[string]int{"a": 1},
+    Skipped:        "value",
+  }
+
+  conf, err := From(&src)
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{
+    "name":    "app",
+    "port":    uint64(8080),
+    "ratio":   float64(0.5),
+    "count":   int32(3),
+    "color":   "#ff8000",
+    "range":   ConfigArr{float64(1), float64(10)},
+    "timeout": "1m30s",
+    "started": started,
+    "labels":  Config{"a": 1},
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+
+  // Round trip
+  var target testEncodeConfig
+  if err := conf.Decode(&target); nil != err {
+    t.Fatal(err)
+  }
+  src.Extra = &testEncodeBase{Name: "app", Port: 8080}
+  src.testEncodeBase.Port = 8080
+  src.Skipped = ""
+  if !reflect.DeepEqual(src, target) {
+    t.Errorf("expected %+v, got %+v", src, target)
+  }
+}
+
+func TestFromStructRoundTripFormats(t *testing.T) {
+  src := testEncodeConfig{
+    testEncodeBase: testEncodeBase{Name: "app", Port: 8080},
+    Extra:          &testEncodeBase{Name: "app", Port: 8080},
+    Port:           8080,
+    Ratio:          0.25,
+    Color:          testColor{B: 255},
+    Range:          testRange{Min: -1, Max: 1},
+    Timeout:        time.Millisecond,
+    Started:        time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+    Tags:           []string{"a", "b"},
+    Child:          &testEncodeBase{Name: "child"},
+  }
+  conf, err := From(src)
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  for _, format := range []string{"json", "yaml", "toml"} {
+    data, err := conf.Encode(format)
+    if nil != err {
+      t.Fatalf("%s: %v", format, err)
+    }
+    decoded, err := FromData(data, format)
+    if nil != err {
+      t.Fatalf("%s: %v", format, err)
+    }
+
+    var target testEncodeConfig
+    if err := decoded.Decode(&target); nil != err {
+      t.Fatalf("%s: %v", format, err)
+    }
+    if !target.Started.Equal(src.Started) {
+      t.Errorf("%s: expected %s, got %s", format, src.Started, target.Started)
+    }
+    target.Started = src.Started
+    if !reflect.DeepEqual(src, target) {
+      t.Errorf("%s: expected %+v, got %+v", format, src, target)
+    }
+  }
+}
+
+func TestFromFieldTag(t *testing.T) {
+  type legacy struct {
+    Host    string `field:"host"`
+    Port    int    `field:"port" config:"db_port"`
+    Skipped string `field:"-"`
+    Name    string
+  }
+
+  conf, err := From(legacy{Host: "localhost", Port: 5432, Skipped: "value", Name: "app"})
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{"host": "localhost", "db_port": 5432, "Name": "app"}
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+
+  var target legacy
+  if err := conf.Decode(&target); nil != err {
+    t.Fatal(err)
+  }
+  if (legacy{Host: "localhost", Port: 5432, Name: "app"}) != target {
+    t.Errorf("unexpected value %+v", target)
+  }
+}
+
+func TestFromMarshalerError(t *testing.T) {
+  type failing struct {
+    Range testRange  `config:"range"`
+    Color *testColor `config:"color"`
+  }
+  var target failing
+  err := (Config{"range": ConfigArr{1}, "color": "red"}).Decode(&target)
+  if nil == err || !strings.Contains(err.Error(), "range: expected two values") ||
+    !strings.Contains(err.Error(), "color:") {
+    t.Errorf("expected errors of the unmarshalers, got %v", err)
+  }
+}
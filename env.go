@@ -0,0 +1,106 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "os"
+  "strings"
+)
+
+// EnvOptions describes mapping of environment variables into the Config
+type EnvOptions struct {
+  // Prefix of the variables, other variables are skipped.
+  // Prefix "APP" matches APP_DB__HOST as well as "APP_".
+  Prefix string
+
+  // Separator of the nested keys, e.g. "__" maps DB__HOST to db.host.
+  // Digit keys are array indexes: SERVERS__0__HOST is servers.0.host
+  Separator string
+
+  // Coerce turns variable values like "8080" or "true" into numbers and booleans
+  Coerce bool
+}
+
+// FromEnv loads environment variables with the prefix, names are converted
+// to lower case and split into nested keys by the separator
+func FromEnv(prefix, separator string) Config {
+  return FromEnviron(os.Environ(), EnvOptions{Prefix: prefix, Separator: separator})
+}
+
+// FromEnviron converts list of "KEY=value" strings into the Config
+func FromEnviron(environ []string, opts EnvOptions) Config {
+  return make(Config).UpdateEnviron(environ, opts)
+}
+
+// UpdateEnv sets environment variable values over the config.
+// Unlike Update with FromEnv it keeps other items of the arrays.
+func (conf Config) UpdateEnv(opts EnvOptions) Config {
+  return conf.UpdateEnviron(os.Environ(), opts)
+}
+
+// UpdateEnviron sets values of the "KEY=value" list over the config
+func (conf Config) UpdateEnviron(environ []string, opts EnvOptions) Config {
+  return conf.updateEnviron(environ, opts, nil)
+}
+
+// updateEnviron sets the values and writes names of the variables
+// into the origins if it's not nil
+func (conf Config) updateEnviron(environ []string, opts EnvOptions, origins Origins) Config {
+  vars := make([]keyValue, 0, len(environ))
+  for _, kv := range environ {
+    eq := strings.IndexByte(kv, '=')
+    if eq < 1 {
+      continue
+    }
+
+    name, ok := trimEnvPrefix(kv[:eq], opts.Prefix)
+    if !ok || len(name) < 1 {
+      continue
+    }
+
+    name = strings.ToLower(name)
+    path := []string{name}
+    if len(opts.Separator) > 0 {
+      path = strings.Split(name, opts.Separator)
+    }
+    vars = append(vars, keyValue{key: kv[:eq], path: path, value: kv[eq+1:]})
+  }
+
+  return conf.setPathValues(vars, opts.Coerce, origins)
+}
+
+func trimEnvPrefix(name, prefix string) (string, bool) {
+  if !strings.HasPrefix(name, prefix) {
+    return "", false
+  }
+  name = name[len(prefix):]
+  if len(prefix) > 0 && !strings.HasSuffix(prefix, "_") {
+    if !strings.HasPrefix(name, "_") {
+      return "", false
+    }
+    name = name[1:]
+  }
+  return name, true
+}
@@ -26,11 +26,117 @@ package config
 
 import (
   "errors"
+  "fmt"
+  "strings"
+  "unicode/utf8"
 )
 
 var (
-  ErrInvalidPath         = errors.New("Invalid path")
-  ErrNoValue             = errors.New("No value")
-  ErrNoValid             = errors.New("No valid")
-  ErrInvalidConfigFormat = errors.New("Invalid config format")
+  ErrInvalidPath          = errors.New("Invalid path")
+  ErrNoValue              = errors.New("No value")
+  ErrNoValid              = errors.New("No valid")
+  ErrInvalidConfigFormat  = errors.New("Invalid config format")
+  ErrInvalidUnicodeEscape = errors.New("Invalid unicode escape")
+  ErrUnsupportedValue     = errors.New("Unsupported value")
+  ErrIncludeCycle         = errors.New("Include cycle")
+  ErrSizeLimit            = errors.New("Size limit exceeded")
+  ErrInvalidTarget        = errors.New("Target must be non nil pointer")
 )
+
+// ParseError describes a syntax error in the config source
+type ParseError struct {
+  Format string
+  Line   int
+  Column int
+  Msg    string
+}
+
+func (e *ParseError) Error() string {
+  if e.Column < 1 {
+    return fmt.Sprintf("%s: line %d: %s", e.Format, e.Line, e.Msg)
+  }
+  return fmt.Sprintf("%s: line %d, column %d: %s", e.Format, e.Line, e.Column, e.Msg)
+}
+
+func newParseError(format string, data []byte, pos int, msg string, args ...interface{}) *ParseError {
+  if pos > len(data) {
+    pos = len(data)
+  }
+
+  line, lineStart := 1, 0
+  for i := 0; i < pos; i++ {
+    if '\n' == data[i] {
+      line++
+      lineStart = i + 1
+    }
+  }
+
+  return &ParseError{
+    Format: format,
+    Line:   line,
+    Column: utf8.RuneCount(data[lineStart:pos]) + 1,
+    Msg:    fmt.Sprintf(msg, args...),
+  }
+}
+
+// KeyError is returned when the map key can't be used as the config key
+type KeyError struct {
+  Path string // Path of the map which contains the key
+  Key  interface{}
+  Msg  string
+}
+
+func (e *KeyError) Error() string {
+  if len(e.Path) < 1 {
+    return fmt.Sprintf("Invalid key %#v: %s", e.Key, e.Msg)
+  }
+  return fmt.Sprintf("Invalid key %#v in %q: %s", e.Key, e.Path, e.Msg)
+}
+
+// DecodeError describes the config value which can't be decoded into the field
+type DecodeError struct {
+  Path  string
+  Value interface{}
+  Msg   string
+}
+
+func (e *DecodeError) Error() string {
+  if len(e.Path) < 1 {
+    return fmt.Sprintf("%s (value %#v)", e.Msg, e.Value)
+  }
+  return fmt.Sprintf("%s: %s (value %#v)", e.Path, e.Msg, e.Value)
+}
+
+// DecodeErrors is the list of all values which can't be decoded
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+  msgs := make([]string, 0, len(e))
+  for _, err := range e {
+    msgs = append(msgs, err.Error())
+  }
+  return strings.Join(msgs, "; ")
+}
+
+// ValidationError describes the config value which doesn't match the rule
+type ValidationError struct {
+  Path  string
+  Value interface{}
+  Rule  string // Rule with the parameter, e.g. min=1
+  Msg   string
+}
+
+func (e *ValidationError) Error() string {
+  return fmt.Sprintf("%s: %s (rule %s, value %#v)", e.Path, e.Msg, e.Rule, e.Value)
+}
+
+// ValidationErrors is the list of all invalid values
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+  msgs := make([]string, 0, len(e))
+  for _, err := range e {
+    msgs = append(msgs, err.Error())
+  }
+  return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,158 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "flag"
+  "strconv"
+  "time"
+)
+
+// Flag describes command line flag bound to the config path
+type Flag struct {
+  Path    string
+  Name    string // Flag name, the path by default
+  Default interface{}
+  Usage   string
+}
+
+// FlagBinding keeps flags registered in the flag set
+type FlagBinding struct {
+  flags *flag.FlagSet
+  paths map[string]string
+}
+
+// flagValue parses the flag by the type of default value
+type flagValue struct {
+  def   interface{}
+  value interface{}
+}
+
+// BindFlags registers flags in the flag set
+func BindFlags(flags *flag.FlagSet, list []Flag) *FlagBinding {
+  b := &FlagBinding{flags: flags, paths: make(map[string]string, len(list))}
+  for _, f := range list {
+    name := f.Name
+    if len(name) < 1 {
+      name = f.Path
+    }
+    flags.Var(&flagValue{def: f.Default, value: f.Default}, name, f.Usage)
+    b.paths[name] = f.Path
+  }
+  return b
+}
+
+// BindConfigFlags registers flag for each scalar value of the config,
+// current values are used as defaults. Arrays are skipped.
+func BindConfigFlags(flags *flag.FlagSet, conf Config) *FlagBinding {
+  return BindFlags(flags, configFlags(nil, "", conf))
+}
+
+func configFlags(list []Flag, path string, conf Config) []Flag {
+  for _, key := range sortedKeys(conf) {
+    switch v := conf[key].(type) {
+    case nil, ConfigArr:
+      break
+    case Config:
+      list = configFlags(list, joinPath(path, key), v)
+      break
+    default:
+      list = append(list, Flag{Path: joinPath(path, key), Default: v})
+      break
+    }
+  }
+  return list
+}
+
+// Config returns values of the flags explicitly set in the command line
+func (b *FlagBinding) Config() Config {
+  return b.Apply(make(Config))
+}
+
+// Apply sets values of the flags explicitly set in the command line
+// over the config. It must be called after flag set parsing.
+func (b *FlagBinding) Apply(conf Config) Config {
+  return b.apply(conf, nil)
+}
+
+// apply sets the values and writes names of the flags
+// into the origins if it's not nil
+func (b *FlagBinding) apply(conf Config, origins Origins) Config {
+  b.flags.Visit(func(f *flag.Flag) {
+    if path, ok := b.paths[f.Name]; ok {
+      conf.Set(path, f.Value.(*flagValue).value)
+      if nil != origins {
+        origins[path] = Origin{Key: "-" + f.Name}
+      }
+    }
+  })
+  return conf
+}
+
+func (v *flagValue) String() string {
+  if nil == v {
+    return ""
+  }
+  if d, ok := v.value.(time.Duration); ok {
+    return d.String()
+  }
+  return scalarToString(v.value)
+}
+
+func (v *flagValue) Set(s string) error {
+  var (
+    value interface{}
+    err   error
+  )
+  switch v.def.(type) {
+  case bool:
+    value, err = strconv.ParseBool(s)
+    break
+  case int, int8, int16, int32, int64:
+    value, err = strconv.ParseInt(s, 10, 64)
+    break
+  case uint, uint8, uint16, uint32, uint64:
+    value, err = strconv.ParseUint(s, 10, 64)
+    break
+  case float32, float64:
+    value, err = strconv.ParseFloat(s, 64)
+    break
+  case time.Duration:
+    value, err = time.ParseDuration(s)
+    break
+  default:
+    value = s
+    break
+  }
+  if nil == err {
+    v.value = value
+  }
+  return err
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+  _, ok := v.def.(bool)
+  return ok
+}
@@ -0,0 +1,93 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "flag"
+  "io/ioutil"
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestBindFlags(t *testing.T) {
+  flags := flag.NewFlagSet("app", flag.ContinueOnError)
+  binding := BindFlags(flags, []Flag{
+    {Path: "db.host", Name: "db-host", Default: "localhost"},
+    {Path: "db.port", Default: 5432},
+    {Path: "debug", Default: false},
+    {Path: "ratio", Default: 0.5},
+    {Path: "timeout", Default: time.Second},
+  })
+  if err := flags.Parse([]string{"-db.port=6432", "-debug", "-timeout", "5s"}); nil != err {
+    t.Fatal(err)
+  }
+
+  // Only explicitly set flags are applied, defaults don't override the config
+  conf := binding.Apply(Config{"db": Config{"host": "db.local"}, "ratio": 1.})
+  expect := Config{
+    "db":      Config{"host": "db.local", "port": int64(6432)},
+    "debug":   true,
+    "ratio":   1.,
+    "timeout": 5 * time.Second,
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+
+  if conf = binding.Config(); 3 != len(conf) || nil != conf["ratio"] {
+    t.Errorf("expected only set flags, got %#v", conf)
+  }
+}
+
+func TestBindFlagsErrors(t *testing.T) {
+  flags := flag.NewFlagSet("app", flag.ContinueOnError)
+  flags.SetOutput(ioutil.Discard)
+  BindFlags(flags, []Flag{{Path: "port", Default: 80}})
+  if err := flags.Parse([]string{"-port=http"}); nil == err {
+    t.Error("expected invalid value error")
+  }
+}
+
+func TestBindConfigFlags(t *testing.T) {
+  flags := flag.NewFlagSet("app", flag.ContinueOnError)
+  binding := BindConfigFlags(flags, Config{
+    "db":   Config{"host": "localhost", "port": 5432},
+    "list": ConfigArr{1, 2},
+  })
+  if nil != flags.Lookup("list") {
+    t.Error("arrays must be skipped")
+  }
+  if f := flags.Lookup("db.port"); nil == f || "5432" != f.DefValue {
+    t.Errorf("expected db.port flag with the default 5432, got %#v", f)
+  }
+
+  if err := flags.Parse([]string{"-db.host", "db.local"}); nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"db": Config{"host": "db.local"}}); !reflect.DeepEqual(expect, binding.Config()) {
+    t.Errorf("expected %#v, got %#v", expect, binding.Config())
+  }
+}
@@ -0,0 +1,413 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "encoding/json"
+  "gopkg.in/yaml.v3"
+  "io"
+  "path/filepath"
+  "regexp"
+  "strconv"
+  "strings"
+  "sync"
+  "unicode/utf8"
+)
+
+// Codec converts data of the format into the config and back
+type Codec interface {
+  // Decode parses data into the map[string]interface{} or Config
+  Decode(data []byte) (interface{}, error)
+
+  // Encode writes the config in the format
+  Encode(conf Config) ([]byte, error)
+}
+
+type (
+  // DecodeFunc parses data into the map[string]interface{} or Config
+  DecodeFunc func(data []byte) (interface{}, error)
+
+  // EncodeFunc writes the config in the format
+  EncodeFunc func(conf Config) ([]byte, error)
+)
+
+// DecodeLinesFunc parses data and writes source lines of the values
+// by the dotted paths into the lines map
+type DecodeLinesFunc func(data []byte, lines map[string]int) (interface{}, error)
+
+type codecFuncs struct {
+  decode DecodeFunc
+  encode EncodeFunc
+}
+
+type lineCodecFuncs struct {
+  codecFuncs
+  decodeLines DecodeLinesFunc
+}
+
+var (
+  // Lines of YAML block mappings (key: with nested block or flow value)
+  // and sequences, the formats with assignments don't have such lines
+  sniffYAMLLineEx   = regexp.MustCompile(`^(\s*- |\s*-$|\s*[^\s=#][^=]*:(\s+[\[{&*|>'"].*|\s*)$|\s+[^\s=#][^=]*:\s)`)
+  sniffTOMLValueEx  = regexp.MustCompile(`^[^=]+=\s*[\[{]`)
+  sniffSectionEx    = regexp.MustCompile(`^\[[^\[\]=]+\]$`)
+  sniffAssignmentEx = regexp.MustCompile(`^(export\s+)?[^\s=:\[\-][^=:]*[=:]`)
+
+  formatsMx  sync.RWMutex
+  formats    = map[string]Codec{}
+  extensions = map[string]string{}
+  jsonCodec  = NewCodec(decodeJSON, Config.JSONPrettify)
+)
+
+func init() {
+  RegisterFormat("json", []string{".json"}, jsonCodec)
+  RegisterFormat("jsonc", []string{".jsonc"}, NewCodec(
+    func(data []byte) (interface{}, error) { return decodeJSON5("jsonc", data) }, Config.JSONPrettify,
+  ))
+  RegisterFormat("json5", []string{".json5"}, NewCodec(
+    func(data []byte) (interface{}, error) { return decodeJSON5("json5", data) }, Config.JSONPrettify,
+  ))
+  RegisterFormat("yaml", []string{".yml", ".yaml"}, yamlCodec{})
+  RegisterFormat("yml", nil, FormatCodec("yaml"))
+  RegisterFormat("toml", []string{".toml"}, NewLineCodec(decodeTOML, encodeTOML))
+  RegisterFormat("xml", []string{".xml"}, NewCodec(
+    func(data []byte) (interface{}, error) { return decodeXML(data, XMLDefaults) },
+    func(conf Config) ([]byte, error) { return encodeXML(conf, XMLDefaults) },
+  ))
+  RegisterFormat("ini", []string{".ini"}, NewLineCodec(
+    func(data []byte, lines map[string]int) (interface{}, error) { return decodeINI(data, INIDefaults, lines) },
+    func(conf Config) ([]byte, error) { return encodeINI(conf, INIDefaults) },
+  ))
+  RegisterFormat("properties", []string{".properties"}, NewLineCodec(decodeProperties, nil))
+  RegisterFormat("env", []string{".env"}, NewLineCodec(
+    func(data []byte, lines map[string]int) (interface{}, error) { return decodeDotEnv(data, DotEnvDefaults, lines) }, nil,
+  ))
+  RegisterFormat("dotenv", nil, FormatCodec("env"))
+}
+
+// MultiCodec is implemented by the codecs of formats
+// which may contain several documents in one stream
+type MultiCodec interface {
+  Codec
+  DecodeMulti(data []byte) ([]interface{}, error)
+}
+
+// LineCodec is implemented by the codecs of formats
+// which can report source lines of the values
+type LineCodec interface {
+  Codec
+  DecodeLines(data []byte) (interface{}, map[string]int, error)
+}
+
+// NewCodec from the functions, nil function means
+// the operation is not supported by the format
+func NewCodec(decode DecodeFunc, encode EncodeFunc) Codec {
+  return &codecFuncs{decode: decode, encode: encode}
+}
+
+func (c *codecFuncs) Decode(data []byte) (interface{}, error) {
+  if nil == c.decode {
+    return nil, ErrInvalidConfigFormat
+  }
+  return c.decode(data)
+}
+
+func (c *codecFuncs) Encode(conf Config) ([]byte, error) {
+  if nil == c.encode {
+    return nil, ErrInvalidConfigFormat
+  }
+  return c.encode(conf)
+}
+
+// NewLineCodec from the functions, the codec implements LineCodec
+func NewLineCodec(decode DecodeLinesFunc, encode EncodeFunc) Codec {
+  return &lineCodecFuncs{
+    codecFuncs: codecFuncs{
+      decode: func(data []byte) (interface{}, error) { return decode(data, nil) },
+      encode: encode,
+    },
+    decodeLines: decode,
+  }
+}
+
+func (c *lineCodecFuncs) DecodeLines(data []byte) (interface{}, map[string]int, error) {
+  lines := make(map[string]int)
+  info, err := c.decodeLines(data, lines)
+  if nil != err {
+    return nil, nil, err
+  }
+  return info, lines, nil
+}
+
+// RegisterFormat adds or replaces the format by the name, the format
+// is selected by the file extensions in FromFile and ToFile.
+// It's safe to register formats concurrently with loading.
+func RegisterFormat(name string, exts []string, codec Codec) {
+  formatsMx.Lock()
+  defer formatsMx.Unlock()
+
+  name = strings.ToLower(name)
+  formats[name] = codec
+  for _, ext := range exts {
+    if !strings.HasPrefix(ext, ".") {
+      ext = "." + ext
+    }
+    extensions[strings.ToLower(ext)] = name
+  }
+}
+
+// RegisterDecoder adds decode only format
+func RegisterDecoder(name string, exts []string, decode DecodeFunc) {
+  RegisterFormat(name, exts, NewCodec(decode, nil))
+}
+
+// FormatCodec returns codec by the format name or nil
+func FormatCodec(name string) Codec {
+  formatsMx.RLock()
+  defer formatsMx.RUnlock()
+  return formats[strings.ToLower(name)]
+}
+
+// FormatByExtension returns format name by the file extension
+// or empty string if extension is unknown
+func FormatByExtension(filename string) string {
+  formatsMx.RLock()
+  defer formatsMx.RUnlock()
+  return extensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// DetectFormat returns the name of the first format which can decode the data
+// or empty string if no one is suitable
+func DetectFormat(data []byte) string {
+  format, _, _ := sniffData(data)
+  return format
+}
+
+// sniffData tries to decode the data by the formats most likely
+// suitable for the content, the error of the most likely format
+// is returned if no one is suitable
+func sniffData(data []byte) (format string, conf Config, err error) {
+  for _, name := range sniffCandidates(data) {
+    c, e := FromData(data, name)
+    if nil == e {
+      return name, c, nil
+    }
+    if nil == err {
+      err = e // Keep the error of the most likely format
+    }
+  }
+  if nil == err {
+    err = ErrInvalidConfigFormat
+  }
+  return "", nil, err
+}
+
+func sniffCandidates(data []byte) []string {
+  data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+  data = bytes.TrimSpace(data)
+  if len(data) < 1 {
+    return []string{"yaml"}
+  }
+  if !isTextData(data) {
+    return nil
+  }
+
+  switch data[0] {
+  case '<':
+    return []string{"xml"}
+  case '{':
+    return []string{"json", "json5"}
+  case '/':
+    return []string{"json5"}
+  }
+
+  var (
+    text       = strings.Replace(string(data), "\r\n", "\n", -1)
+    lines      = strings.Split(text, "\n")
+    yamlLike   = bytes.HasPrefix(data, []byte("---")) || bytes.HasPrefix(data, []byte("%YAML"))
+    assignment = true // Each line is [section], key=value or key: value
+    keys       = 0
+    continued  = false
+  )
+  for _, line := range lines {
+    if continued { // Continuation of the properties value
+      continued = isPropertiesContinued(line)
+      continue
+    }
+    line = strings.TrimRight(line, " \t")
+    trimmed := strings.TrimLeft(line, " \t")
+    if len(trimmed) < 1 || strings.ContainsRune("#;!", rune(trimmed[0])) {
+      continue
+    }
+    switch {
+    case sniffYAMLLineEx.MatchString(line):
+      yamlLike, assignment = true, false
+      break
+    case sniffAssignmentEx.MatchString(trimmed):
+      // TOML arrays and inline tables are not the values of line based formats
+      if sniffTOMLValueEx.MatchString(trimmed) {
+        assignment = false
+      }
+      keys++
+      break
+    case !sniffSectionEx.MatchString(trimmed):
+      assignment = false
+      break
+    }
+    continued = isPropertiesContinued(trimmed)
+  }
+
+  assignment = assignment && keys > 0
+  if '[' == data[0] {
+    if assignment {
+      return []string{"toml", "ini", "json", "json5"}
+    }
+    return []string{"json", "json5", "toml", "yaml"}
+  }
+
+  formats := []string{"toml", "yaml"}
+  if yamlLike {
+    formats = []string{"yaml", "toml"}
+  }
+  if assignment {
+    // Line based formats accept almost any text, so they are tried
+    // only if each line looks like the assignment
+    formats = append(formats, "env", "ini", "properties")
+  }
+  return formats
+}
+
+// isTextData returns false for the binary data
+func isTextData(data []byte) bool {
+  if !utf8.Valid(data) {
+    return false
+  }
+  for _, c := range data {
+    if c < ' ' && '\n' != c && '\r' != c && '\t' != c && '\f' != c {
+      return false
+    }
+  }
+  return true
+}
+
+func decodeJSON(data []byte) (info interface{}, err error) {
+  err = json.Unmarshal(data, &info)
+  return
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Decode(data []byte) (info interface{}, err error) {
+  err = yaml.Unmarshal(data, &info)
+  return
+}
+
+// DecodeMulti returns the value for each document of the stream,
+// empty documents are nil, so indexes match the document sections
+func (yamlCodec) DecodeMulti(data []byte) ([]interface{}, error) {
+  var (
+    decoder = yaml.NewDecoder(bytes.NewReader(data))
+    result  = make([]interface{}, 0, 1)
+  )
+  for {
+    var info interface{}
+    if err := decoder.Decode(&info); nil != err {
+      if io.EOF == err {
+        return result, nil
+      }
+      return nil, err
+    }
+    result = append(result, info)
+  }
+}
+
+// DecodeLines decodes the document and reports lines of the keys
+// and sequence items
+func (yamlCodec) DecodeLines(data []byte) (interface{}, map[string]int, error) {
+  var (
+    node  yaml.Node
+    info  interface{}
+    lines = make(map[string]int)
+  )
+  if err := yaml.Unmarshal(data, &node); nil != err {
+    return nil, nil, err
+  }
+  if 0 == len(node.Content) {
+    return nil, lines, nil
+  }
+  if err := node.Decode(&info); nil != err {
+    return nil, nil, err
+  }
+  yamlNodeLines(node.Content[0], "", lines)
+  return info, lines, nil
+}
+
+func (yamlCodec) Encode(conf Config) ([]byte, error) {
+  return yaml.Marshal(conf)
+}
+
+func yamlNodeLines(node *yaml.Node, path string, lines map[string]int) {
+  if yaml.AliasNode == node.Kind {
+    node = node.Alias
+  }
+
+  switch node.Kind {
+  case yaml.MappingNode:
+    // Explicit keys override the merged ones, so merges go first
+    for i := 0; i+1 < len(node.Content); i += 2 {
+      if key := node.Content[i]; "!!merge" == key.Tag {
+        yamlMergeLines(node.Content[i+1], path, lines)
+      }
+    }
+    for i := 0; i+1 < len(node.Content); i += 2 {
+      if key := node.Content[i]; "!!merge" != key.Tag {
+        keyPath := joinPath(path, key.Value)
+        lines[keyPath] = key.Line
+        yamlNodeLines(node.Content[i+1], keyPath, lines)
+      }
+    }
+    break
+  case yaml.SequenceNode:
+    for i, it := range node.Content {
+      itemPath := joinPath(path, strconv.Itoa(i))
+      lines[itemPath] = it.Line
+      yamlNodeLines(it, itemPath, lines)
+    }
+    break
+  }
+}
+
+// yamlMergeLines reports lines of the merged mapping or the sequence
+// of mappings, the first mapping in the sequence has priority
+func yamlMergeLines(node *yaml.Node, path string, lines map[string]int) {
+  if yaml.SequenceNode != node.Kind {
+    yamlNodeLines(node, path, lines)
+    return
+  }
+  for i := len(node.Content) - 1; i >= 0; i-- {
+    yamlNodeLines(node.Content[i], path, lines)
+  }
+}
@@ -0,0 +1,246 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "strings"
+)
+
+// DotEnvOptions describes mapping of .env files into the Config
+type DotEnvOptions struct {
+  // Separator splits names into nested paths, e.g. with "__"
+  // DB__HOST becomes DB.HOST; empty value keeps names as is
+  Separator string
+
+  // LowerCase converts names to lower case
+  LowerCase bool
+}
+
+var (
+  // DotEnvDefaults is used by FromData and FromFile
+  DotEnvDefaults = DotEnvOptions{}
+)
+
+// FromDotEnv decodes .env file with the custom mapping options
+func FromDotEnv(data []byte, opts DotEnvOptions) (Config, error) {
+  info, err := decodeDotEnv(data, opts, nil)
+  if nil != err {
+    return nil, err
+  }
+  return info.(Config), nil
+}
+
+// decodeDotEnv parses the data, lines of the values are written
+// into the lines map if it's not nil
+func decodeDotEnv(data []byte, opts DotEnvOptions, lines map[string]int) (interface{}, error) {
+  p := &dotEnvParser{data: []byte(strings.Replace(string(data), "\r\n", "\n", -1))}
+  conf := make(Config)
+
+  for {
+    p.skipBlank()
+    if p.eof() {
+      break
+    }
+
+    start := p.pos
+    key, value, err := p.parseLine()
+    if nil != err {
+      return nil, err
+    }
+
+    if opts.LowerCase {
+      key = strings.ToLower(key)
+    }
+    if len(opts.Separator) > 0 {
+      path := strings.Split(key, opts.Separator)
+      conf.SetPath(path, value)
+      key = strings.Join(path, ".")
+    } else {
+      conf[key] = value
+    }
+    if nil != lines {
+      lines[key] = bytes.Count(p.data[:start], []byte("\n")) + 1
+    }
+  }
+  return conf, nil
+}
+
+type dotEnvParser struct {
+  data []byte
+  pos  int
+}
+
+func (p *dotEnvParser) parseLine() (key, value string, err error) {
+  if p.hasPrefix("export ") || p.hasPrefix("export\t") {
+    p.pos += len("export")
+    p.skipSpaces()
+  }
+
+  start := p.pos
+  for !p.eof() && isDotEnvKeyChar(p.peek()) {
+    p.pos++
+  }
+  if start == p.pos {
+    return "", "", p.errorf("expected variable name")
+  }
+  key = string(p.data[start:p.pos])
+
+  p.skipSpaces()
+  if '=' != p.peek() {
+    return "", "", p.errorf("expected = after variable name")
+  }
+  p.pos++
+  p.skipSpaces()
+
+  switch p.peek() {
+  case '"':
+    value, err = p.parseQuoted('"')
+    break
+  case '\'':
+    value, err = p.parseQuoted('\'')
+    break
+  default:
+    value = p.parseUnquoted()
+    return key, value, nil
+  }
+
+  if nil == err {
+    p.skipSpaces()
+    if !p.eof() && '\n' != p.peek() && '#' != p.peek() {
+      err = p.errorf("unexpected character after quoted value")
+    }
+    p.skipLine()
+  }
+  return key, value, err
+}
+
+// parseQuoted reads value in quotes, it may span multiple lines.
+// Escape sequences are processed in double quotes only.
+func (p *dotEnvParser) parseQuoted(q byte) (string, error) {
+  start := p.pos
+  p.pos++
+
+  var buf []byte
+  for !p.eof() {
+    c := p.peek()
+    p.pos++
+
+    if q == c {
+      return string(buf), nil
+    }
+    if '\\' == c && '"' == q && !p.eof() {
+      c = p.peek()
+      p.pos++
+      switch c {
+      case 'n':
+        buf = append(buf, '\n')
+        break
+      case 'r':
+        buf = append(buf, '\r')
+        break
+      case 't':
+        buf = append(buf, '\t')
+        break
+      case '"', '\\', '$':
+        buf = append(buf, c)
+        break
+      default:
+        buf = append(buf, '\\', c)
+        break
+      }
+      continue
+    }
+    buf = append(buf, c)
+  }
+  return "", newParseError("env", p.data, start, "unterminated quoted value")
+}
+
+func (p *dotEnvParser) parseUnquoted() string {
+  start := p.pos
+  p.skipLine()
+  value := string(p.data[start:p.pos])
+
+  // Inline comment must be separated by whitespace
+  for i := 0; i < len(value); i++ {
+    if '#' == value[i] && (0 == i || ' ' == value[i-1] || '\t' == value[i-1]) {
+      value = value[:i]
+      break
+    }
+  }
+  return strings.TrimSpace(value)
+}
+
+func (p *dotEnvParser) eof() bool {
+  return p.pos >= len(p.data)
+}
+
+func (p *dotEnvParser) peek() byte {
+  if p.eof() {
+    return 0
+  }
+  return p.data[p.pos]
+}
+
+func (p *dotEnvParser) hasPrefix(prefix string) bool {
+  return strings.HasPrefix(string(p.data[p.pos:]), prefix)
+}
+
+func (p *dotEnvParser) skipSpaces() {
+  for !p.eof() && (' ' == p.peek() || '\t' == p.peek()) {
+    p.pos++
+  }
+}
+
+func (p *dotEnvParser) skipLine() {
+  for !p.eof() && '\n' != p.peek() {
+    p.pos++
+  }
+}
+
+// skipBlank skips whitespaces, empty lines and comments
+func (p *dotEnvParser) skipBlank() {
+  for !p.eof() {
+    switch p.peek() {
+    case ' ', '\t', '\n':
+      p.pos++
+      break
+    case '#':
+      p.skipLine()
+      break
+    default:
+      return
+    }
+  }
+}
+
+func (p *dotEnvParser) errorf(format string, args ...interface{}) error {
+  return newParseError("env", p.data, p.pos, format, args...)
+}
+
+func isDotEnvKeyChar(c byte) bool {
+  return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+    '_' == c || '.' == c || '-' == c
+}
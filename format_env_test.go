@@ -0,0 +1,94 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+)
+
+func TestDecodeDotEnv(t *testing.T) {
+  data := "# comment\r\n" +
+    "export NAME=app\n" +
+    "PLAIN = value with spaces # comment\n" +
+    "HASH=a#b\n" +
+    "DQ=\"line1\\nline2 \\\"q\\\"\"\n" +
+    "SQ='raw\\n'\n" +
+    "MULTI=\"a\n" +
+    "b\"\n" +
+    "EMPTY=\n"
+  conf, err := FromData([]byte(data), "env")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  expect := Config{
+    "NAME":  "app",
+    "PLAIN": "value with spaces",
+    "HASH":  "a#b",
+    "DQ":    "line1\nline2 \"q\"",
+    "SQ":    `raw\n`,
+    "MULTI": "a\nb",
+    "EMPTY": "",
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestFromDotEnvOptions(t *testing.T) {
+  conf, err := FromDotEnv([]byte("DB__HOST=localhost\nDB__PORT=5432\n"), DotEnvOptions{Separator: "__", LowerCase: true})
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{"db": Config{"host": "localhost", "port": "5432"}}
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestDecodeDotEnvErrors(t *testing.T) {
+  tests := []struct {
+    data string
+    line int
+  }{
+    {data: "A=1\n=2", line: 2},
+    {data: "A=1\nB 2", line: 2},
+    {data: "A=\"open\nB=1", line: 1},
+    {data: "A=1\nB='x' y", line: 2},
+  }
+  for _, test := range tests {
+    _, err := FromData([]byte(test.data), "env")
+    var perr *ParseError
+    if !errors.As(err, &perr) {
+      t.Errorf("%q: expected ParseError, got %v", test.data, err)
+      continue
+    }
+    if test.line != perr.Line {
+      t.Errorf("%q: expected line %d, got %d (%v)", test.data, test.line, perr.Line, err)
+    }
+  }
+}
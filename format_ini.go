@@ -0,0 +1,207 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "fmt"
+  "strings"
+)
+
+// INIOptions describes mapping of INI files into the Config
+type INIOptions struct {
+  // Subsections turns dotted section names like [db.master]
+  // into nested keys
+  Subsections bool
+}
+
+var (
+  // INIDefaults is used by FromData and FromFile
+  INIDefaults = INIOptions{Subsections: true}
+)
+
+// FromINI decodes INI file with the custom mapping options.
+// Keys defined before the first section are stored at the top level.
+func FromINI(data []byte, opts INIOptions) (Config, error) {
+  info, err := decodeINI(data, opts, nil)
+  if nil != err {
+    return nil, err
+  }
+  return From(info)
+}
+
+// decodeINI parses the data, lines of the values are written
+// into the lines map if it's not nil
+func decodeINI(data []byte, opts INIOptions, lines map[string]int) (interface{}, error) {
+  var (
+    result      = make(map[string]interface{})
+    section     = result
+    sectionPath = ""
+    text        = strings.Replace(string(data), "\r\n", "\n", -1)
+    offset      = 0
+  )
+
+  for i, line := range strings.Split(text, "\n") {
+    lineOffset := offset
+    offset += len(line) + 1
+    line = strings.TrimSpace(line)
+
+    if len(line) < 1 || ';' == line[0] || '#' == line[0] {
+      continue
+    }
+
+    if '[' == line[0] {
+      end := strings.IndexByte(line, ']')
+      if end < 0 {
+        return nil, newParseError("ini", []byte(text), lineOffset, "expected ] at the end of section")
+      }
+
+      name := strings.TrimSpace(line[1:end])
+      if len(name) < 1 {
+        return nil, newParseError("ini", []byte(text), lineOffset, "empty section name")
+      }
+
+      path := []string{name}
+      if opts.Subsections {
+        path = strings.Split(name, ".")
+      }
+      section = iniSection(result, path)
+      sectionPath = strings.Join(path, ".")
+      continue
+    }
+
+    sep := strings.IndexAny(line, "=:")
+    if sep < 0 {
+      return nil, newParseError("ini", []byte(text), lineOffset, "expected = or : after key")
+    }
+
+    key := strings.TrimSpace(line[:sep])
+    if len(key) < 1 {
+      return nil, newParseError("ini", []byte(text), lineOffset, "empty key")
+    }
+    section[key] = iniValue(strings.TrimSpace(line[sep+1:]))
+    if nil != lines {
+      lines[joinPath(sectionPath, key)] = i + 1
+    }
+  }
+  return result, nil
+}
+
+func iniSection(root map[string]interface{}, path []string) map[string]interface{} {
+  section := root
+  for _, key := range path {
+    if sub, ok := section[key].(map[string]interface{}); ok {
+      section = sub
+    } else {
+      sub = make(map[string]interface{})
+      section[key] = sub
+      section = sub
+    }
+  }
+  return section
+}
+
+// iniValue unquotes the value or strips the inline comment
+func iniValue(value string) string {
+  if len(value) > 1 {
+    if q := value[0]; ('"' == q || '\'' == q) && strings.IndexByte(value[1:], q) >= 0 {
+      return value[1 : strings.IndexByte(value[1:], q)+1]
+    }
+  }
+
+  for i := 1; i < len(value); i++ {
+    if (';' == value[i] || '#' == value[i]) && (' ' == value[i-1] || '\t' == value[i-1]) {
+      return strings.TrimSpace(value[:i])
+    }
+  }
+  return value
+}
+
+// encodeINI writes top level values first and then the sections,
+// arrays are not supported by the format
+func encodeINI(conf Config, opts INIOptions) ([]byte, error) {
+  var buf bytes.Buffer
+  if err := encodeINISection(&buf, nil, conf, opts); nil != err {
+    return nil, err
+  }
+  return buf.Bytes(), nil
+}
+
+func encodeINISection(buf *bytes.Buffer, path []string, conf Config, opts INIOptions) error {
+  keys := sortedKeys(conf)
+  if len(path) > 0 {
+    if buf.Len() > 0 {
+      buf.WriteByte('\n')
+    }
+    buf.WriteString("[" + strings.Join(path, ".") + "]\n")
+  }
+
+  for _, key := range keys {
+    switch v := conf[key].(type) {
+    case Config:
+      continue
+    case ConfigArr:
+      return fmt.Errorf("ini: %s: %w", strings.Join(append(path, key), "."), ErrUnsupportedValue)
+    default:
+      value := scalarToString(v)
+      if !isINIKey(key) || strings.ContainsAny(value, "\r\n") {
+        return fmt.Errorf("ini: %s: %w", strings.Join(append(path, key), "."), ErrUnsupportedValue)
+      }
+      buf.WriteString(key + " = " + iniQuote(value) + "\n")
+      break
+    }
+  }
+
+  for _, key := range keys {
+    sub, ok := conf[key].(Config)
+    if !ok {
+      continue
+    }
+    if len(path) > 0 && !opts.Subsections {
+      return fmt.Errorf("ini: %s: %w", strings.Join(append(path, key), "."), ErrUnsupportedValue)
+    }
+    if err := encodeINISection(buf, append(path[:len(path):len(path)], key), sub, opts); nil != err {
+      return err
+    }
+  }
+  return nil
+}
+
+// iniQuote quotes values which would be changed by the decoder
+func iniQuote(value string) string {
+  if len(value) > 0 && (strings.TrimSpace(value) != value || strings.ContainsAny(value, ";#") ||
+    '"' == value[0] || '\'' == value[0]) {
+    if strings.IndexByte(value, '"') < 0 {
+      return `"` + value + `"`
+    }
+    return `'` + value + `'`
+  }
+  return value
+}
+
+func isINIKey(key string) bool {
+  return len(key) > 0 && strings.TrimSpace(key) == key &&
+    !strings.ContainsAny(key, "=:\r\n") && strings.IndexByte(";#[", key[0]) < 0
+}
@@ -0,0 +1,95 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+)
+
+func TestDecodeINI(t *testing.T) {
+  data := "; comment\r\n" +
+    "# comment\n" +
+    "name = app\n" +
+    "\n" +
+    "[db]\n" +
+    "host: localhost\n" +
+    "port = 5432 ; inline comment\n" +
+    "quoted = \"a ; b\"\n" +
+    "url = http://host/#anchor\n" +
+    "\n" +
+    "[db.replica]\n" +
+    "host = replica\n"
+  conf, err := FromData([]byte(data), "ini")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  expect := Config{
+    "name": "app",
+    "db": Config{
+      "host":    "localhost",
+      "port":    "5432",
+      "quoted":  "a ; b",
+      "url":     "http://host/#anchor",
+      "replica": Config{"host": "replica"},
+    },
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+
+  conf, err = FromINI([]byte("[db.replica]\nhost = replica\n"), INIOptions{})
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"db.replica": Config{"host": "replica"}}); !reflect.DeepEqual(expect, conf) {
+    t.Errorf("without subsections: expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestDecodeINIErrors(t *testing.T) {
+  tests := []struct {
+    data string
+    line int
+  }{
+    {data: "a = 1\n[db", line: 2},
+    {data: "[]", line: 1},
+    {data: "a = 1\n\nvalue", line: 3},
+    {data: "= 1", line: 1},
+  }
+  for _, test := range tests {
+    _, err := FromData([]byte(test.data), "ini")
+    var perr *ParseError
+    if !errors.As(err, &perr) {
+      t.Errorf("%q: expected ParseError, got %v", test.data, err)
+      continue
+    }
+    if test.line != perr.Line {
+      t.Errorf("%q: expected line %d, got %d (%v)", test.data, test.line, perr.Line, err)
+    }
+  }
+}
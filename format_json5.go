@@ -0,0 +1,417 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "math"
+  "regexp"
+  "strconv"
+  "strings"
+  "unicode"
+  "unicode/utf8"
+)
+
+var (
+  json5NumberEx = regexp.MustCompile(`^([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+)
+
+type json5Parser struct {
+  format string
+  data   []byte
+  pos    int
+}
+
+// decodeJSON5 parses relaxed JSON with comments, trailing commas, unquoted keys,
+// single quoted strings and hex numbers. The result has the same types
+// as encoding/json produces for interface{}.
+func decodeJSON5(format string, data []byte) (interface{}, error) {
+  p := &json5Parser{format: format, data: data}
+  if len(data) >= 3 && "\xEF\xBB\xBF" == string(data[:3]) {
+    p.pos = 3
+  }
+
+  if err := p.skipBlank(); nil != err {
+    return nil, err
+  }
+  value, err := p.parseValue()
+  if nil != err {
+    return nil, err
+  }
+  if err = p.skipBlank(); nil != err {
+    return nil, err
+  }
+  if !p.eof() {
+    return nil, p.errorf("unexpected character %q after the value", p.peek())
+  }
+  return value, nil
+}
+
+func (p *json5Parser) parseValue() (interface{}, error) {
+  if p.eof() {
+    return nil, p.errorf("unexpected end of data")
+  }
+
+  switch c := p.peek(); {
+  case '{' == c:
+    return p.parseObject()
+  case '[' == c:
+    return p.parseArray()
+  case '"' == c || '\'' == c:
+    return p.parseString()
+  case '-' == c || '+' == c || '.' == c || (c >= '0' && c <= '9'):
+    return p.parseNumber()
+  }
+
+  start := p.pos
+  switch ident := p.parseIdentifier(); ident {
+  case "true":
+    return true, nil
+  case "false":
+    return false, nil
+  case "null":
+    return nil, nil
+  case "Infinity":
+    return math.Inf(1), nil
+  case "NaN":
+    return math.NaN(), nil
+  }
+  p.pos = start
+  return nil, p.errorf("unexpected character %q", p.peek())
+}
+
+func (p *json5Parser) parseObject() (interface{}, error) {
+  p.pos++ // {
+  obj := make(map[string]interface{})
+  for {
+    if err := p.skipBlank(); nil != err {
+      return nil, err
+    }
+    if '}' == p.peek() {
+      p.pos++
+      return obj, nil
+    }
+
+    var (
+      key string
+      err error
+    )
+    if c := p.peek(); '"' == c || '\'' == c {
+      key, err = p.parseString()
+    } else if key = p.parseIdentifier(); len(key) < 1 {
+      err = p.errorf("expected object key")
+    }
+    if nil != err {
+      return nil, err
+    }
+
+    if err = p.skipBlank(); nil != err {
+      return nil, err
+    }
+    if ':' != p.peek() {
+      return nil, p.errorf("expected : after object key")
+    }
+    p.pos++
+    if err = p.skipBlank(); nil != err {
+      return nil, err
+    }
+
+    if obj[key], err = p.parseValue(); nil != err {
+      return nil, err
+    }
+
+    if err = p.skipBlank(); nil != err {
+      return nil, err
+    }
+    switch p.peek() {
+    case ',':
+      p.pos++
+      break
+    case '}':
+      p.pos++
+      return obj, nil
+    default:
+      return nil, p.errorf("expected , or } in object")
+    }
+  }
+}
+
+func (p *json5Parser) parseArray() (interface{}, error) {
+  p.pos++ // [
+  arr := make([]interface{}, 0)
+  for {
+    if err := p.skipBlank(); nil != err {
+      return nil, err
+    }
+    if ']' == p.peek() {
+      p.pos++
+      return arr, nil
+    }
+
+    value, err := p.parseValue()
+    if nil != err {
+      return nil, err
+    }
+    arr = append(arr, value)
+
+    if err = p.skipBlank(); nil != err {
+      return nil, err
+    }
+    switch p.peek() {
+    case ',':
+      p.pos++
+      break
+    case ']':
+      p.pos++
+      return arr, nil
+    default:
+      return nil, p.errorf("expected , or ] in array")
+    }
+  }
+}
+
+func (p *json5Parser) parseString() (string, error) {
+  start := p.pos
+  quote := p.peek()
+  p.pos++
+
+  var buf []byte
+  for {
+    if p.eof() {
+      return "", p.errorAt(start, "unterminated string")
+    }
+
+    c := p.peek()
+    switch {
+    case quote == c:
+      p.pos++
+      return string(buf), nil
+    case '\n' == c:
+      return "", p.errorf("newline in string")
+    case '\\' == c:
+      r, err := p.parseEscape()
+      if nil != err {
+        return "", err
+      }
+      buf = append(buf, r...)
+      break
+    default:
+      buf = append(buf, c)
+      p.pos++
+      break
+    }
+  }
+}
+
+func (p *json5Parser) parseEscape() ([]byte, error) {
+  start := p.pos
+  p.pos++ // \
+  if p.eof() {
+    return nil, p.errorAt(start, "unterminated string")
+  }
+
+  c := p.peek()
+  p.pos++
+  switch c {
+  case 'b':
+    return []byte{'\b'}, nil
+  case 'f':
+    return []byte{'\f'}, nil
+  case 'n':
+    return []byte{'\n'}, nil
+  case 'r':
+    return []byte{'\r'}, nil
+  case 't':
+    return []byte{'\t'}, nil
+  case 'v':
+    return []byte{'\v'}, nil
+  case '0':
+    return []byte{0}, nil
+  case '\n':
+    return nil, nil // Line continuation
+  case '\r':
+    if '\n' == p.peek() {
+      p.pos++
+    }
+    return nil, nil
+  case 'x':
+    if p.pos+2 > len(p.data) {
+      return nil, p.errorAt(start, "invalid hex escape")
+    }
+    code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+2]), 16, 8)
+    if nil != err {
+      return nil, p.errorAt(start, "invalid hex escape")
+    }
+    p.pos += 2
+    return []byte(string(rune(code))), nil
+  case 'u':
+    r, err := p.parseUnicode(start)
+    if nil != err {
+      return nil, err
+    }
+    // UTF-16 surrogate pair
+    if r >= 0xD800 && r < 0xDC00 && p.pos+6 <= len(p.data) && `\u` == string(p.data[p.pos:p.pos+2]) {
+      pos := p.pos
+      p.pos += 2
+      if low, err := p.parseUnicode(pos); nil == err && low >= 0xDC00 && low < 0xE000 {
+        r = (r-0xD800)<<10 + (low - 0xDC00) + 0x10000
+      } else {
+        p.pos = pos
+      }
+    }
+    return []byte(string(r)), nil
+  }
+
+  size := 1
+  if c >= utf8.RuneSelf {
+    _, size = utf8.DecodeRune(p.data[p.pos-1:])
+  }
+  p.pos += size - 1
+  return p.data[p.pos-size : p.pos], nil
+}
+
+func (p *json5Parser) parseUnicode(start int) (rune, error) {
+  if p.pos+4 > len(p.data) {
+    return 0, p.errorAt(start, "invalid unicode escape")
+  }
+  code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+4]), 16, 16)
+  if nil != err {
+    return 0, p.errorAt(start, "invalid unicode escape")
+  }
+  p.pos += 4
+  return rune(code), nil
+}
+
+func (p *json5Parser) parseNumber() (interface{}, error) {
+  start := p.pos
+  for !p.eof() && isJSON5NumberChar(p.peek()) {
+    p.pos++
+  }
+  token := string(p.data[start:p.pos])
+
+  sign := 1.
+  number := token
+  if strings.HasPrefix(number, "-") {
+    sign, number = -1, number[1:]
+  } else if strings.HasPrefix(number, "+") {
+    number = number[1:]
+  }
+
+  switch {
+  case "Infinity" == number:
+    return sign * math.Inf(1), nil
+  case "NaN" == number:
+    return math.NaN(), nil
+  case strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "0X"):
+    v, err := strconv.ParseUint(number[2:], 16, 64)
+    if nil != err {
+      return nil, p.errorAt(start, "invalid number %q", token)
+    }
+    return sign * float64(v), nil
+  case len(number) > 1 && '0' == number[0] && '.' != number[1] && 'e' != number[1] && 'E' != number[1]:
+    return nil, p.errorAt(start, "invalid number %q", token)
+  }
+
+  if !json5NumberEx.MatchString(number) {
+    return nil, p.errorAt(start, "invalid number %q", token)
+  }
+  v, err := strconv.ParseFloat(number, 64)
+  if nil != err {
+    return nil, p.errorAt(start, "invalid number %q", token)
+  }
+  return sign * v, nil
+}
+
+// parseIdentifier reads ECMAScript-like identifier, unicode escapes
+// are not supported
+func (p *json5Parser) parseIdentifier() string {
+  start := p.pos
+  for !p.eof() {
+    r, size := utf8.DecodeRune(p.data[p.pos:])
+    if '$' == r || '_' == r || unicode.IsLetter(r) || (p.pos > start && (unicode.IsDigit(r) ||
+      unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || unicode.Is(unicode.Pc, r))) {
+      p.pos += size
+      continue
+    }
+    break
+  }
+  return string(p.data[start:p.pos])
+}
+
+// skipBlank skips whitespaces and comments
+func (p *json5Parser) skipBlank() error {
+  for !p.eof() {
+    switch c := p.peek(); {
+    case ' ' == c || '\t' == c || '\n' == c || '\r' == c || '\f' == c || '\v' == c:
+      p.pos++
+      break
+    case '/' == c && p.pos+1 < len(p.data) && '/' == p.data[p.pos+1]:
+      for !p.eof() && '\n' != p.peek() {
+        p.pos++
+      }
+      break
+    case '/' == c && p.pos+1 < len(p.data) && '*' == p.data[p.pos+1]:
+      end := strings.Index(string(p.data[p.pos+2:]), "*/")
+      if end < 0 {
+        return p.errorf("unterminated comment")
+      }
+      p.pos += end + 4
+      break
+    case c >= utf8.RuneSelf:
+      r, size := utf8.DecodeRune(p.data[p.pos:])
+      if !unicode.IsSpace(r) && '\uFEFF' != r {
+        return nil
+      }
+      p.pos += size
+      break
+    default:
+      return nil
+    }
+  }
+  return nil
+}
+
+func (p *json5Parser) eof() bool {
+  return p.pos >= len(p.data)
+}
+
+func (p *json5Parser) peek() byte {
+  if p.eof() {
+    return 0
+  }
+  return p.data[p.pos]
+}
+
+func (p *json5Parser) errorf(format string, args ...interface{}) error {
+  return p.errorAt(p.pos, format, args...)
+}
+
+func (p *json5Parser) errorAt(pos int, format string, args ...interface{}) error {
+  return newParseError(p.format, p.data, pos, format, args...)
+}
+
+func isJSON5NumberChar(c byte) bool {
+  return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+    '.' == c || '+' == c || '-' == c
+}
@@ -0,0 +1,108 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "math"
+  "reflect"
+  "testing"
+)
+
+func TestDecodeJSON5(t *testing.T) {
+  data := `// comment
+{
+  unquoted: 'single',
+  "quoted": "esc\"apedA",
+  /* block */ hex: 0x1F,
+  float: .5,
+  plus: +1,
+  inf: -Infinity,
+  nan: NaN,
+  list: [1, 'two', {a: true},],
+  multi: 'line\
+ continued',
+}`
+  conf, err := FromData([]byte(data), "json5")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "unquoted", expect: "single"},
+    {path: "quoted", expect: `esc"apedA`},
+    {path: "hex", expect: 31.},
+    {path: "float", expect: .5},
+    {path: "plus", expect: 1.},
+    {path: "inf", expect: math.Inf(-1)},
+    {path: "list.1", expect: "two"},
+    {path: "list.2.a", expect: true},
+    {path: "multi", expect: "line continued"},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+  if v, _ := conf.Get("nan"); !math.IsNaN(v.(float64)) {
+    t.Errorf("nan: expected NaN, got %v", v)
+  }
+
+  conf, err = FromData([]byte("{\n  // comment\n  \"a\": [1, 2,],\n}"), "jsonc")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if v, _ := conf.Get("a.1"); 2. != v {
+    t.Errorf("jsonc a.1: expected 2, got %#v", v)
+  }
+}
+
+func TestDecodeJSON5Errors(t *testing.T) {
+  tests := []struct {
+    data string
+    line int
+  }{
+    {data: "{a: 1", line: 1},
+    {data: "{\n  a: 01\n}", line: 2},
+    {data: "{\n  a: 'open\n}", line: 2},
+    {data: "{a: 1} x", line: 1},
+    {data: "{\n  /* open", line: 2},
+    {data: "[1,,2]", line: 1},
+  }
+  for _, test := range tests {
+    _, err := FromData([]byte(test.data), "json5")
+    var perr *ParseError
+    if !errors.As(err, &perr) {
+      t.Errorf("%q: expected ParseError, got %v", test.data, err)
+      continue
+    }
+    if test.line != perr.Line {
+      t.Errorf("%q: expected line %d, got %d (%v)", test.data, test.line, perr.Line, err)
+    }
+  }
+}
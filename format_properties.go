@@ -0,0 +1,161 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "strconv"
+  "strings"
+)
+
+// decodeProperties parses Java .properties file, dotted keys
+// are expanded into nested keys by the Config.Set. Lines of the values
+// are written into the lines map if it's not nil.
+func decodeProperties(data []byte, lines map[string]int) (interface{}, error) {
+  var (
+    conf   = make(Config)
+    text   = strings.Replace(string(data), "\r\n", "\n", -1)
+    list   = strings.Split(text, "\n")
+    offset = 0
+  )
+
+  for i := 0; i < len(list); i++ {
+    lineOffset, lineNumber := offset, i+1
+    line := strings.TrimLeft(list[i], " \t\f")
+    offset += len(list[i]) + 1
+
+    if len(line) < 1 || '#' == line[0] || '!' == line[0] {
+      continue
+    }
+
+    // Join continuation lines
+    for isPropertiesContinued(line) && i+1 < len(list) {
+      i++
+      offset += len(list[i]) + 1
+      line = line[:len(line)-1] + strings.TrimLeft(list[i], " \t\f")
+    }
+    if isPropertiesContinued(line) {
+      line = line[:len(line)-1]
+    }
+
+    key, value := splitProperty(line)
+    key, err := unescapeProperty(key)
+    if nil == err {
+      value, err = unescapeProperty(value)
+    }
+    if nil != err {
+      return nil, newParseError("properties", []byte(text), lineOffset, "%v", err)
+    }
+    conf.Set(key, value)
+    if nil != lines {
+      lines[key] = lineNumber
+    }
+  }
+  return conf, nil
+}
+
+func isPropertiesContinued(line string) bool {
+  n := 0
+  for i := len(line) - 1; i >= 0 && '\\' == line[i]; i-- {
+    n++
+  }
+  return 1 == n%2
+}
+
+// splitProperty splits the line by the first unescaped =, : or whitespace
+func splitProperty(line string) (key, value string) {
+  for i := 0; i < len(line); i++ {
+    switch line[i] {
+    case '\\':
+      i++
+      break
+    case '=', ':':
+      return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+    case ' ', '\t', '\f':
+      value = strings.TrimLeft(line[i:], " \t\f")
+      if len(value) > 0 && ('=' == value[0] || ':' == value[0]) {
+        value = strings.TrimLeft(value[1:], " \t\f")
+      }
+      return line[:i], value
+    }
+  }
+  return line, ""
+}
+
+func unescapeProperty(s string) (string, error) {
+  if strings.IndexByte(s, '\\') < 0 {
+    return s, nil
+  }
+
+  buf := make([]byte, 0, len(s))
+  for i := 0; i < len(s); i++ {
+    if '\\' != s[i] || i+1 >= len(s) {
+      buf = append(buf, s[i])
+      continue
+    }
+
+    i++
+    switch s[i] {
+    case 't':
+      buf = append(buf, '\t')
+      break
+    case 'n':
+      buf = append(buf, '\n')
+      break
+    case 'r':
+      buf = append(buf, '\r')
+      break
+    case 'f':
+      buf = append(buf, '\f')
+      break
+    case 'u':
+      if i+5 > len(s) {
+        return "", ErrInvalidUnicodeEscape
+      }
+      code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+      if nil != err {
+        return "", ErrInvalidUnicodeEscape
+      }
+      r := rune(code)
+
+      // UTF-16 surrogate pair
+      if r >= 0xD800 && r < 0xDC00 {
+        if i+11 <= len(s) && `\u` == s[i+5:i+7] {
+          if low, err := strconv.ParseUint(s[i+7:i+11], 16, 16); nil == err && low >= 0xDC00 && low < 0xE000 {
+            r = (r-0xD800)<<10 + (rune(low) - 0xDC00) + 0x10000
+            i += 6
+          }
+        }
+      }
+
+      buf = append(buf, string(r)...)
+      i += 4
+      break
+    default:
+      buf = append(buf, s[i])
+      break
+    }
+  }
+  return string(buf), nil
+}
@@ -0,0 +1,78 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+)
+
+func TestDecodeProperties(t *testing.T) {
+  data := "# comment\n" +
+    "! comment\n" +
+    "app.name = demo\n" +
+    "app.port:8080\n" +
+    "app.title Hello World\n" +
+    "long = first, \\\n" +
+    "       second\n" +
+    "key\\:with\\=sep = value\n" +
+    "unicode = caf\\u00e9\n" +
+    "emoji = \\ud83d\\ude00\n" +
+    "escapes = a\\tb\\nc\n" +
+    "empty\n"
+  conf, err := FromData([]byte(data), "properties")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "app.name", expect: "demo"},
+    {path: "app.port", expect: "8080"},
+    {path: "app.title", expect: "Hello World"},
+    {path: "long", expect: "first, second"},
+    {path: "key:with=sep", expect: "value"},
+    {path: "unicode", expect: "café"},
+    {path: "emoji", expect: "\U0001F600"},
+    {path: "escapes", expect: "a\tb\nc"},
+    {path: "empty", expect: ""},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+}
+
+func TestDecodePropertiesErrors(t *testing.T) {
+  _, err := FromData([]byte("a = 1\nb = \\u12"), "properties")
+  var perr *ParseError
+  if !errors.As(err, &perr) || 2 != perr.Line {
+    t.Errorf("expected ParseError at line 2, got %v", err)
+  }
+}
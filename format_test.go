@@ -0,0 +1,191 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "sync"
+  "testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+  tests := []struct {
+    data   string
+    format string
+  }{
+    {data: `{"a": 1}`, format: "json"},
+    {data: "{a: 1, // comment\n}", format: "json5"},
+    {data: "// comment\n{\"a\": 1}", format: "json5"},
+    {data: "<config><a>1</a></config>", format: "xml"},
+    {data: "a:\n  b: 1\nlist:\n  - x\n", format: "yaml"},
+    {data: "---\na: 1\n", format: "yaml"},
+    {data: "name: app\nport: 80\n", format: "yaml"},
+    {data: "\xEF\xBB\xBFa = 1\n[db]\nhost = \"localhost\"\n", format: "toml"},
+    {data: "[[items]]\nn = 1\n", format: "toml"},
+    {data: "# comment\nexport NAME=hello world\nPORT=80\n", format: "env"},
+    {data: "; comment\n[db]\nhost = local host\n", format: "ini"},
+    {data: "[db]\nhost: localhost\n", format: "ini"},
+    {data: "app.name = demo \\\n  continued\napp.title = Hello\nkey\\=x = \\u00e9\n", format: "properties"},
+    {data: "", format: "yaml"},
+  }
+  for _, test := range tests {
+    if format := DetectFormat([]byte(test.data)); test.format != format {
+      t.Errorf("%q: expected %q, got %q", test.data, test.format, format)
+    }
+  }
+}
+
+func TestFromDataDetectErrors(t *testing.T) {
+  tests := []struct {
+    data   string
+    format string // Format of the reported error
+  }{
+    {data: "a: [1, 2\nb: 3", format: "yaml"},
+    {data: "- 1\n- 2", format: "yaml"},
+    {data: "a:\n  b: 1\n c: 2\n", format: "yaml"},
+    {data: "[1, 2]", format: "json"},
+    {data: "a = [1, 2\n", format: "toml"},
+    {data: "just some text", format: ""},
+    {data: "\x00\x01\x02binary\xff", format: ""},
+  }
+  for _, test := range tests {
+    conf, err := FromData([]byte(test.data), "")
+    if nil == err {
+      t.Errorf("%q: expected error, got %#v", test.data, conf)
+      continue
+    }
+    if perr, ok := err.(*ParseError); ok && len(test.format) > 0 && perr.Format != test.format {
+      t.Errorf("%q: expected %s error, got %v", test.data, test.format, err)
+    }
+    if format := DetectFormat([]byte(test.data)); "" != format {
+      t.Errorf("%q: expected no format, got %q", test.data, format)
+    }
+  }
+}
+
+func TestRegisterFormat(t *testing.T) {
+  // Lines of "key value" pairs
+  decode := func(data []byte) (interface{}, error) {
+    conf := make(Config)
+    for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+      if kv := strings.SplitN(line, " ", 2); 2 == len(kv) {
+        conf.Set(kv[0], kv[1])
+      }
+    }
+    return conf, nil
+  }
+  encode := func(conf Config) ([]byte, error) {
+    var buf strings.Builder
+    walkLeaves(conf, "", func(path string, value interface{}) {
+      buf.WriteString(path + " " + scalarToString(value) + "\n")
+    })
+    return []byte(buf.String()), nil
+  }
+  RegisterFormat("TestKV", []string{"tkv", ".TKV2"}, NewCodec(decode, encode))
+
+  for _, name := range []string{"a.tkv", "a.TKV", "dir/a.tkv2"} {
+    if format := FormatByExtension(name); "testkv" != format {
+      t.Errorf("%s: expected testkv format, got %q", name, format)
+    }
+  }
+
+  filename := filepath.Join(t.TempDir(), "app.tkv")
+  if err := (Config{"db": Config{"host": "localhost"}}).ToFile(filename, ""); nil != err {
+    t.Fatal(err)
+  }
+  conf, err := FromFile(filename, "")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if "localhost" != conf.String("db.host") {
+    t.Errorf("expected db.host from the custom format, got %#v", conf)
+  }
+
+  RegisterDecoder("testdecoder", nil, decode)
+  if _, err = (Config{"a": 1}).Encode("testdecoder"); !errors.Is(err, ErrInvalidConfigFormat) {
+    t.Errorf("decoder: expected ErrInvalidConfigFormat on encode, got %v", err)
+  }
+  if _, err = FromData([]byte("a 1"), "unknown"); !errors.Is(err, ErrInvalidConfigFormat) {
+    t.Errorf("unknown format: expected ErrInvalidConfigFormat, got %v", err)
+  }
+}
+
+func TestFormatCodecInterfaces(t *testing.T) {
+  tests := []struct {
+    format string
+    multi  bool
+    lines  bool
+  }{
+    {format: "json"},
+    {format: "yaml", multi: true, lines: true},
+    {format: "yml", multi: true, lines: true},
+    {format: "toml", lines: true},
+    {format: "ini", lines: true},
+    {format: "properties", lines: true},
+    {format: "env", lines: true},
+    {format: "xml"},
+  }
+  for _, test := range tests {
+    codec := FormatCodec(test.format)
+    if nil == codec {
+      t.Errorf("%s: format is not registered", test.format)
+      continue
+    }
+    if _, ok := codec.(MultiCodec); test.multi != ok {
+      t.Errorf("%s: expected MultiCodec=%v", test.format, test.multi)
+    }
+    if _, ok := codec.(LineCodec); test.lines != ok {
+      t.Errorf("%s: expected LineCodec=%v", test.format, test.lines)
+    }
+  }
+
+  // Codecs without multi document support return the single config
+  list, err := FromDataMulti([]byte(`{"a": 1}`), "json")
+  if nil != err || 1 != len(list) {
+    t.Errorf("json: expected single config, got %#v (%v)", list, err)
+  }
+}
+
+func TestRegisterFormatConcurrent(t *testing.T) {
+  var wg sync.WaitGroup
+  for i := 0; i < 4; i++ {
+    wg.Add(2)
+    go func(i int) {
+      defer wg.Done()
+      RegisterFormat("testconcurrent"+strconv.Itoa(i), []string{".tc" + strconv.Itoa(i)}, jsonCodec)
+    }(i)
+    go func() {
+      defer wg.Done()
+      if _, err := FromData([]byte(`{"a": 1}`), "json"); nil != err {
+        t.Error(err)
+      }
+      FormatByExtension("a.tc0")
+    }()
+  }
+  wg.Wait()
+}
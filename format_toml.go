@@ -0,0 +1,1031 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "fmt"
+  "math"
+  "regexp"
+  "strconv"
+  "strings"
+  "time"
+  "unicode/utf8"
+
+  "github.com/demdxx/gocast"
+)
+
+var (
+  tomlDecimalEx  = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+  tomlHexEx      = regexp.MustCompile(`^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$`)
+  tomlOctalEx    = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+  tomlBinaryEx   = regexp.MustCompile(`^0b[01](_?[01])*$`)
+  tomlFloatEx    = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+  tomlDateEx     = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+  tomlTimeEx     = regexp.MustCompile(`^[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?$`)
+  tomlDateTimeEx = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2})[Tt ]([0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?)([Zz]|[+-][0-9]{2}:[0-9]{2})?$`)
+)
+
+// tomlTable is a table under construction; the flags follow the TOML rules
+// for which tables may be extended later in the document
+type tomlTable struct {
+  values map[string]interface{}
+  path   string // dotted path of the table in the document
+  header bool // defined by a [table] header
+  dotted bool // defined by a dotted key
+  inline bool // inline table, closed for extension
+}
+
+// tomlTableArray is an array defined by [[table]] headers
+type tomlTableArray struct {
+  tables []*tomlTable
+}
+
+type tomlParser struct {
+  data   []byte
+  pos    int
+  root   *tomlTable
+  cur    *tomlTable
+  lines  map[string]int
+  inline int // depth of inline tables, their lines are not tracked
+}
+
+// decodeTOML parses TOML document into map[string]interface{} with
+// []interface{} arrays, int64, float64, bool, string and time.Time values.
+// Local date-times and dates are returned in the time.Local location,
+// local times as time.Time with zero date. Lines of the values are written
+// into the lines map if it's not nil.
+func decodeTOML(data []byte, lines map[string]int) (interface{}, error) {
+  p := &tomlParser{data: data, root: newTomlTable(""), lines: lines}
+  p.cur = p.root
+  if err := p.parse(); nil != err {
+    return nil, err
+  }
+  return p.root.toMap(), nil
+}
+
+func newTomlTable(path string) *tomlTable {
+  return &tomlTable{values: make(map[string]interface{}), path: path}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Document
+///////////////////////////////////////////////////////////////////////////////
+
+func (p *tomlParser) parse() error {
+  if len(p.data) >= 3 && "\xEF\xBB\xBF" == string(p.data[:3]) {
+    p.pos = 3
+  }
+  for {
+    p.skipBlank()
+    if p.eof() {
+      return nil
+    }
+
+    var err error
+    if '[' == p.peek() {
+      err = p.parseHeader()
+    } else {
+      err = p.parseKeyValue(p.cur)
+    }
+    if nil != err {
+      return err
+    }
+    if err = p.expectLineEnd(); nil != err {
+      return err
+    }
+  }
+}
+
+func (p *tomlParser) parseHeader() error {
+  start := p.pos
+  isArray := p.hasPrefix("[[")
+  if isArray {
+    p.pos += 2
+  } else {
+    p.pos++
+  }
+
+  p.skipSpaces()
+  keys, err := p.parseKey()
+  if nil != err {
+    return err
+  }
+  p.skipSpaces()
+
+  if isArray {
+    if !p.hasPrefix("]]") {
+      return p.errorf("expected ]] at the end of table array header")
+    }
+    p.pos += 2
+  } else {
+    if ']' != p.peek() {
+      return p.errorf("expected ] at the end of table header")
+    }
+    p.pos++
+  }
+
+  tbl := p.root
+  for _, key := range keys[:len(keys)-1] {
+    if tbl, err = p.descend(tbl, key, start, false); nil != err {
+      return err
+    }
+  }
+
+  key := keys[len(keys)-1]
+  it, ok := tbl.values[key]
+  if isArray {
+    if !ok {
+      arr := &tomlTableArray{}
+      tbl.values[key] = arr
+      it = arr
+    }
+    arr, ok := it.(*tomlTableArray)
+    if !ok {
+      return p.errorAt(start, "key %q is already defined and is not an array of tables", strings.Join(keys, "."))
+    }
+    p.cur = newTomlTable(joinPath(joinPath(tbl.path, key), strconv.Itoa(len(arr.tables))))
+    p.cur.header = true
+    arr.tables = append(arr.tables, p.cur)
+    return nil
+  }
+
+  if !ok {
+    p.cur = newTomlTable(joinPath(tbl.path, key))
+    p.cur.header = true
+    tbl.values[key] = p.cur
+    return nil
+  }
+
+  sub, ok := it.(*tomlTable)
+  if !ok || sub.inline {
+    return p.errorAt(start, "key %q is already defined and is not a table", strings.Join(keys, "."))
+  }
+  if sub.header || sub.dotted {
+    return p.errorAt(start, "table %q is already defined", strings.Join(keys, "."))
+  }
+  sub.header = true
+  p.cur = sub
+  return nil
+}
+
+// descend returns sub table by the key creating it if necessary
+func (p *tomlParser) descend(tbl *tomlTable, key string, start int, dotted bool) (*tomlTable, error) {
+  it, ok := tbl.values[key]
+  if !ok {
+    sub := newTomlTable(joinPath(tbl.path, key))
+    sub.dotted = dotted
+    tbl.values[key] = sub
+    return sub, nil
+  }
+
+  switch a := it.(type) {
+  case *tomlTable:
+    if a.inline || (dotted && a.header) {
+      return nil, p.errorAt(start, "table %q can't be extended", key)
+    }
+    return a, nil
+  case *tomlTableArray:
+    if dotted {
+      return nil, p.errorAt(start, "key %q is already defined as an array of tables", key)
+    }
+    return a.tables[len(a.tables)-1], nil
+  }
+  return nil, p.errorAt(start, "key %q is already defined and is not a table", key)
+}
+
+func (p *tomlParser) parseKeyValue(tbl *tomlTable) error {
+  start := p.pos
+  keys, err := p.parseKey()
+  if nil != err {
+    return err
+  }
+
+  p.skipSpaces()
+  if '=' != p.peek() {
+    return p.errorf("expected = after key")
+  }
+  p.pos++
+  p.skipSpaces()
+
+  value, err := p.parseValue()
+  if nil != err {
+    return err
+  }
+
+  for _, key := range keys[:len(keys)-1] {
+    if tbl, err = p.descend(tbl, key, start, true); nil != err {
+      return err
+    }
+  }
+
+  key := keys[len(keys)-1]
+  if _, ok := tbl.values[key]; ok {
+    return p.errorAt(start, "key %q is already defined", strings.Join(keys, "."))
+  }
+  tbl.values[key] = value
+  if nil != p.lines && 0 == p.inline {
+    p.lines[joinPath(tbl.path, key)] = bytes.Count(p.data[:start], []byte("\n")) + 1
+  }
+  return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Keys
+///////////////////////////////////////////////////////////////////////////////
+
+func (p *tomlParser) parseKey() ([]string, error) {
+  keys := make([]string, 0, 1)
+  for {
+    key, err := p.parseSimpleKey()
+    if nil != err {
+      return nil, err
+    }
+    keys = append(keys, key)
+
+    p.skipSpaces()
+    if '.' != p.peek() {
+      return keys, nil
+    }
+    p.pos++
+    p.skipSpaces()
+  }
+}
+
+func (p *tomlParser) parseSimpleKey() (string, error) {
+  switch p.peek() {
+  case '"':
+    if p.hasPrefix(`"""`) {
+      return "", p.errorf("multi-line string can't be used as a key")
+    }
+    return p.parseBasicString()
+  case '\'':
+    if p.hasPrefix(`'''`) {
+      return "", p.errorf("multi-line string can't be used as a key")
+    }
+    return p.parseLiteralString()
+  }
+
+  start := p.pos
+  for !p.eof() && isTomlBareKeyChar(p.peek()) {
+    p.pos++
+  }
+  if start == p.pos {
+    return "", p.errorf("expected key")
+  }
+  return string(p.data[start:p.pos]), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Values
+///////////////////////////////////////////////////////////////////////////////
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+  if p.eof() {
+    return nil, p.errorf("expected value")
+  }
+
+  switch p.peek() {
+  case '"':
+    if p.hasPrefix(`"""`) {
+      return p.parseMultilineBasicString()
+    }
+    return p.parseBasicString()
+  case '\'':
+    if p.hasPrefix(`'''`) {
+      return p.parseMultilineLiteralString()
+    }
+    return p.parseLiteralString()
+  case '[':
+    return p.parseArray()
+  case '{':
+    return p.parseInlineTable()
+  }
+  return p.parseScalar()
+}
+
+func (p *tomlParser) parseScalar() (interface{}, error) {
+  start := p.pos
+  for !p.eof() && isTomlValueChar(p.peek()) {
+    p.pos++
+  }
+  token := string(p.data[start:p.pos])
+
+  // Date and time may be separated by space
+  if tomlDateEx.MatchString(token) && p.pos+3 < len(p.data) && ' ' == p.data[p.pos] &&
+    isTomlDigit(p.data[p.pos+1]) && isTomlDigit(p.data[p.pos+2]) && ':' == p.data[p.pos+3] {
+    p.pos++
+    for !p.eof() && isTomlValueChar(p.peek()) {
+      p.pos++
+    }
+    token = string(p.data[start:p.pos])
+  }
+
+  if len(token) < 1 {
+    return nil, p.errorAt(start, "expected value")
+  }
+
+  switch token {
+  case "true":
+    return true, nil
+  case "false":
+    return false, nil
+  case "inf", "+inf":
+    return math.Inf(1), nil
+  case "-inf":
+    return math.Inf(-1), nil
+  case "nan", "+nan", "-nan":
+    return math.NaN(), nil
+  }
+
+  var (
+    v   interface{}
+    err error
+  )
+  switch {
+  case tomlDecimalEx.MatchString(token):
+    v, err = strconv.ParseInt(strings.Replace(token, "_", "", -1), 10, 64)
+  case tomlHexEx.MatchString(token):
+    v, err = strconv.ParseInt(strings.Replace(token[2:], "_", "", -1), 16, 64)
+  case tomlOctalEx.MatchString(token):
+    v, err = strconv.ParseInt(strings.Replace(token[2:], "_", "", -1), 8, 64)
+  case tomlBinaryEx.MatchString(token):
+    v, err = strconv.ParseInt(strings.Replace(token[2:], "_", "", -1), 2, 64)
+  case tomlFloatEx.MatchString(token):
+    v, err = strconv.ParseFloat(strings.Replace(token, "_", "", -1), 64)
+  case tomlDateTimeEx.MatchString(token):
+    v, err = parseTomlDateTime(token)
+  case tomlDateEx.MatchString(token):
+    v, err = time.ParseInLocation("2006-01-02", token, time.Local)
+  case tomlTimeEx.MatchString(token):
+    v, err = time.Parse("15:04:05.999999999", token)
+  default:
+    return nil, p.errorAt(start, "invalid value %q", token)
+  }
+
+  if nil != err {
+    return nil, p.errorAt(start, "invalid value %q", token)
+  }
+  return v, nil
+}
+
+func parseTomlDateTime(token string) (time.Time, error) {
+  m := tomlDateTimeEx.FindStringSubmatch(token)
+  value := m[1] + "T" + m[2]
+  if len(m[4]) < 1 {
+    return time.ParseInLocation("2006-01-02T15:04:05.999999999", value, time.Local)
+  }
+  return time.Parse("2006-01-02T15:04:05.999999999Z07:00", value+strings.ToUpper(m[4]))
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+  p.pos++ // [
+  arr := make([]interface{}, 0)
+  for {
+    p.skipBlank()
+    if p.eof() {
+      return nil, p.errorf("unterminated array")
+    }
+    if ']' == p.peek() {
+      p.pos++
+      return arr, nil
+    }
+
+    v, err := p.parseValue()
+    if nil != err {
+      return nil, err
+    }
+    arr = append(arr, v)
+
+    p.skipBlank()
+    switch p.peek() {
+    case ',':
+      p.pos++
+      break
+    case ']':
+      p.pos++
+      return arr, nil
+    default:
+      return nil, p.errorf("expected , or ] in array")
+    }
+  }
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+  p.pos++ // {
+  tbl := newTomlTable("")
+  p.inline++
+  defer func() { p.inline-- }()
+
+  p.skipSpaces()
+  if '}' == p.peek() {
+    p.pos++
+    tbl.inline = true
+    return tbl, nil
+  }
+
+  for {
+    p.skipSpaces()
+    if err := p.parseKeyValue(tbl); nil != err {
+      return nil, err
+    }
+    p.skipSpaces()
+
+    switch p.peek() {
+    case ',':
+      p.pos++
+      break
+    case '}':
+      p.pos++
+      tbl.freeze()
+      return tbl, nil
+    default:
+      return nil, p.errorf("expected , or } in inline table")
+    }
+  }
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Strings
+///////////////////////////////////////////////////////////////////////////////
+
+func (p *tomlParser) parseBasicString() (string, error) {
+  p.pos++ // "
+  var buf []byte
+  for {
+    if p.eof() {
+      return "", p.errorf("unterminated string")
+    }
+
+    c := p.peek()
+    switch {
+    case '"' == c:
+      p.pos++
+      return string(buf), nil
+    case '\\' == c:
+      r, err := p.parseEscape()
+      if nil != err {
+        return "", err
+      }
+      buf = append(buf, r...)
+      break
+    case '\n' == c:
+      return "", p.errorf("newline in string")
+    case isTomlControl(c):
+      return "", p.errorf("control character in string")
+    default:
+      buf = append(buf, c)
+      p.pos++
+      break
+    }
+  }
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+  p.pos += 3 // """
+  p.skipNewline()
+
+  var buf []byte
+  for {
+    if p.eof() {
+      return "", p.errorf("unterminated multi-line string")
+    }
+
+    c := p.peek()
+    switch {
+    case '"' == c && p.hasPrefix(`"""`):
+      n := p.countQuotes('"')
+      if n > 5 {
+        return "", p.errorf("too many quotes in multi-line string")
+      }
+      for i := 3; i < n; i++ {
+        buf = append(buf, '"')
+      }
+      p.pos += n
+      return string(buf), nil
+    case '\\' == c && p.isLineEndingBackslash():
+      p.pos++
+      for !p.eof() && (isTomlSpace(p.peek()) || '\n' == p.peek() || '\r' == p.peek()) {
+        p.pos++
+      }
+      break
+    case '\\' == c:
+      r, err := p.parseEscape()
+      if nil != err {
+        return "", err
+      }
+      buf = append(buf, r...)
+      break
+    case '\n' == c:
+      buf = append(buf, c)
+      p.pos++
+      break
+    case '\r' == c && p.hasPrefix("\r\n"):
+      buf = append(buf, '\n')
+      p.pos += 2
+      break
+    case isTomlControl(c):
+      return "", p.errorf("control character in string")
+    default:
+      buf = append(buf, c)
+      p.pos++
+      break
+    }
+  }
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+  p.pos++ // '
+  start := p.pos
+  for {
+    if p.eof() {
+      return "", p.errorf("unterminated string")
+    }
+
+    c := p.peek()
+    switch {
+    case '\'' == c:
+      p.pos++
+      return string(p.data[start : p.pos-1]), nil
+    case '\n' == c:
+      return "", p.errorf("newline in string")
+    case isTomlControl(c):
+      return "", p.errorf("control character in string")
+    }
+    p.pos++
+  }
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+  p.pos += 3 // '''
+  p.skipNewline()
+
+  var buf []byte
+  for {
+    if p.eof() {
+      return "", p.errorf("unterminated multi-line string")
+    }
+
+    c := p.peek()
+    switch {
+    case '\'' == c && p.hasPrefix(`'''`):
+      n := p.countQuotes('\'')
+      if n > 5 {
+        return "", p.errorf("too many quotes in multi-line string")
+      }
+      for i := 3; i < n; i++ {
+        buf = append(buf, '\'')
+      }
+      p.pos += n
+      return string(buf), nil
+    case '\r' == c && p.hasPrefix("\r\n"):
+      buf = append(buf, '\n')
+      p.pos += 2
+      break
+    case '\n' != c && isTomlControl(c):
+      return "", p.errorf("control character in string")
+    default:
+      buf = append(buf, c)
+      p.pos++
+      break
+    }
+  }
+}
+
+func (p *tomlParser) parseEscape() ([]byte, error) {
+  start := p.pos
+  p.pos++ // \
+  if p.eof() {
+    return nil, p.errorf("unterminated string")
+  }
+
+  c := p.peek()
+  p.pos++
+  switch c {
+  case 'b':
+    return []byte{'\b'}, nil
+  case 't':
+    return []byte{'\t'}, nil
+  case 'n':
+    return []byte{'\n'}, nil
+  case 'f':
+    return []byte{'\f'}, nil
+  case 'r':
+    return []byte{'\r'}, nil
+  case '"':
+    return []byte{'"'}, nil
+  case '\\':
+    return []byte{'\\'}, nil
+  case 'u', 'U':
+    size := 4
+    if 'U' == c {
+      size = 8
+    }
+    if p.pos+size > len(p.data) {
+      return nil, p.errorAt(start, "invalid unicode escape")
+    }
+    code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+size]), 16, 32)
+    if nil != err || !utf8.ValidRune(rune(code)) {
+      return nil, p.errorAt(start, "invalid unicode escape")
+    }
+    p.pos += size
+    buf := make([]byte, utf8.RuneLen(rune(code)))
+    utf8.EncodeRune(buf, rune(code))
+    return buf, nil
+  }
+  return nil, p.errorAt(start, "invalid escape sequence \\%c", c)
+}
+
+func (p *tomlParser) isLineEndingBackslash() bool {
+  for i := p.pos + 1; i < len(p.data); i++ {
+    switch p.data[i] {
+    case ' ', '\t':
+      continue
+    case '\n':
+      return true
+    case '\r':
+      return i+1 < len(p.data) && '\n' == p.data[i+1]
+    }
+    return false
+  }
+  return false
+}
+
+func (p *tomlParser) countQuotes(q byte) int {
+  n := 0
+  for p.pos+n < len(p.data) && q == p.data[p.pos+n] {
+    n++
+  }
+  return n
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Helpers
+///////////////////////////////////////////////////////////////////////////////
+
+func (p *tomlParser) eof() bool {
+  return p.pos >= len(p.data)
+}
+
+func (p *tomlParser) peek() byte {
+  if p.eof() {
+    return 0
+  }
+  return p.data[p.pos]
+}
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+  return strings.HasPrefix(string(p.data[p.pos:]), prefix)
+}
+
+func (p *tomlParser) skipSpaces() {
+  for !p.eof() && isTomlSpace(p.peek()) {
+    p.pos++
+  }
+}
+
+func (p *tomlParser) skipComment() {
+  if '#' != p.peek() {
+    return
+  }
+  for !p.eof() && '\n' != p.peek() {
+    p.pos++
+  }
+}
+
+func (p *tomlParser) skipNewline() {
+  if p.hasPrefix("\n") {
+    p.pos++
+  } else if p.hasPrefix("\r\n") {
+    p.pos += 2
+  }
+}
+
+// skipBlank skips whitespaces, newlines and comments
+func (p *tomlParser) skipBlank() {
+  for !p.eof() {
+    switch p.peek() {
+    case ' ', '\t', '\r', '\n':
+      p.pos++
+      break
+    case '#':
+      p.skipComment()
+      break
+    default:
+      return
+    }
+  }
+}
+
+func (p *tomlParser) expectLineEnd() error {
+  p.skipSpaces()
+  p.skipComment()
+  if p.eof() {
+    return nil
+  }
+  if p.hasPrefix("\n") || p.hasPrefix("\r\n") {
+    p.skipNewline()
+    return nil
+  }
+  return p.errorf("expected newline")
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+  return p.errorAt(p.pos, format, args...)
+}
+
+func (p *tomlParser) errorAt(pos int, format string, args ...interface{}) error {
+  return newParseError("toml", p.data, pos, format, args...)
+}
+
+func (t *tomlTable) freeze() {
+  t.inline = true
+  for _, v := range t.values {
+    if sub, ok := v.(*tomlTable); ok {
+      sub.freeze()
+    }
+  }
+}
+
+func (t *tomlTable) toMap() map[string]interface{} {
+  m := make(map[string]interface{}, len(t.values))
+  for k, v := range t.values {
+    m[k] = tomlToValue(v)
+  }
+  return m
+}
+
+func tomlToValue(v interface{}) interface{} {
+  switch a := v.(type) {
+  case *tomlTable:
+    return a.toMap()
+  case *tomlTableArray:
+    arr := make([]interface{}, 0, len(a.tables))
+    for _, t := range a.tables {
+      arr = append(arr, t.toMap())
+    }
+    return arr
+  case []interface{}:
+    for i, it := range a {
+      a[i] = tomlToValue(it)
+    }
+    return a
+  }
+  return v
+}
+
+func isTomlSpace(c byte) bool {
+  return ' ' == c || '\t' == c
+}
+
+func isTomlDigit(c byte) bool {
+  return c >= '0' && c <= '9'
+}
+
+func isTomlControl(c byte) bool {
+  return (c < 0x20 && '\t' != c) || 0x7f == c
+}
+
+func isTomlBareKeyChar(c byte) bool {
+  return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isTomlDigit(c) || '_' == c || '-' == c
+}
+
+func isTomlValueChar(c byte) bool {
+  return isTomlBareKeyChar(c) || '+' == c || '.' == c || ':' == c
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Encoding
+///////////////////////////////////////////////////////////////////////////////
+
+func encodeTOML(conf Config) ([]byte, error) {
+  var buf bytes.Buffer
+  if err := encodeTOMLTable(&buf, nil, conf); nil != err {
+    return nil, err
+  }
+  return buf.Bytes(), nil
+}
+
+func encodeTOMLTable(buf *bytes.Buffer, path []string, conf Config) error {
+  keys := sortedKeys(conf)
+
+  // Values must go before the sub tables
+  for _, key := range keys {
+    value := conf[key]
+    if nil == value || isTOMLTable(value) {
+      continue
+    }
+    buf.WriteString(tomlKey(key))
+    buf.WriteString(" = ")
+    if err := encodeTOMLValue(buf, value); nil != err {
+      return fmt.Errorf("toml: %s: %w", strings.Join(append(path, key), "."), err)
+    }
+    buf.WriteByte('\n')
+  }
+
+  for _, key := range keys {
+    subpath := append(path[:len(path):len(path)], key)
+    switch v := conf[key].(type) {
+    case Config:
+      writeTOMLHeader(buf, "[", subpath, "]")
+      if err := encodeTOMLTable(buf, subpath, v); nil != err {
+        return err
+      }
+      break
+    case ConfigArr:
+      if !isTOMLTable(v) {
+        continue
+      }
+      for _, it := range v {
+        writeTOMLHeader(buf, "[[", subpath, "]]")
+        if err := encodeTOMLTable(buf, subpath, it.(Config)); nil != err {
+          return err
+        }
+      }
+      break
+    }
+  }
+  return nil
+}
+
+func writeTOMLHeader(buf *bytes.Buffer, open string, path []string, close string) {
+  if buf.Len() > 0 {
+    buf.WriteByte('\n')
+  }
+  buf.WriteString(open)
+  for i, key := range path {
+    if i > 0 {
+      buf.WriteByte('.')
+    }
+    buf.WriteString(tomlKey(key))
+  }
+  buf.WriteString(close)
+  buf.WriteByte('\n')
+}
+
+func encodeTOMLValue(buf *bytes.Buffer, value interface{}) error {
+  switch v := value.(type) {
+  case nil:
+    return ErrUnsupportedValue
+  case string:
+    buf.WriteString(tomlQuote(v))
+    break
+  case bool:
+    buf.WriteString(strconv.FormatBool(v))
+    break
+  case time.Time:
+    buf.WriteString(v.Format(time.RFC3339Nano))
+    break
+  case float32, float64:
+    f := gocast.ToFloat64(v)
+    switch {
+    case math.IsNaN(f):
+      buf.WriteString("nan")
+    case math.IsInf(f, 1):
+      buf.WriteString("inf")
+    case math.IsInf(f, -1):
+      buf.WriteString("-inf")
+    default:
+      buf.WriteString(tomlFloat(v))
+    }
+    break
+  case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+    buf.WriteString(scalarToString(v))
+    break
+  case Config:
+    buf.WriteString("{")
+    count := 0
+    for _, key := range sortedKeys(v) {
+      if nil == v[key] {
+        continue
+      }
+      if count > 0 {
+        buf.WriteString(",")
+      }
+      count++
+      buf.WriteString(" ")
+      buf.WriteString(tomlKey(key))
+      buf.WriteString(" = ")
+      if err := encodeTOMLValue(buf, v[key]); nil != err {
+        return err
+      }
+    }
+    buf.WriteString(" }")
+    break
+  case ConfigArr:
+    buf.WriteString("[")
+    for i, it := range v {
+      if i > 0 {
+        buf.WriteString(", ")
+      }
+      if err := encodeTOMLValue(buf, it); nil != err {
+        return err
+      }
+    }
+    buf.WriteString("]")
+    break
+  default:
+    buf.WriteString(tomlQuote(scalarToString(v)))
+    break
+  }
+  return nil
+}
+
+// tomlFloat writes the float with the fraction or exponent,
+// so integral values are not decoded as integers
+func tomlFloat(value interface{}) string {
+  var s string
+  if f, ok := value.(float32); ok {
+    s = strconv.FormatFloat(float64(f), 'g', -1, 32)
+  } else {
+    s = strconv.FormatFloat(value.(float64), 'g', -1, 64)
+  }
+  if !strings.ContainsAny(s, ".e") {
+    s += ".0"
+  }
+  return s
+}
+
+// isTOMLTable returns true for values written as [table] or [[table]]
+func isTOMLTable(value interface{}) bool {
+  switch v := value.(type) {
+  case Config:
+    return true
+  case ConfigArr:
+    if len(v) < 1 {
+      return false
+    }
+    for _, it := range v {
+      if _, ok := it.(Config); !ok {
+        return false
+      }
+    }
+    return true
+  }
+  return false
+}
+
+func tomlKey(key string) string {
+  if len(key) < 1 {
+    return `""`
+  }
+  for i := 0; i < len(key); i++ {
+    if !isTomlBareKeyChar(key[i]) {
+      return tomlQuote(key)
+    }
+  }
+  return key
+}
+
+func tomlQuote(s string) string {
+  var buf bytes.Buffer
+  buf.WriteByte('"')
+  for _, r := range s {
+    switch r {
+    case '"':
+      buf.WriteString(`\"`)
+    case '\\':
+      buf.WriteString(`\\`)
+    case '\b':
+      buf.WriteString(`\b`)
+    case '\t':
+      buf.WriteString(`\t`)
+    case '\n':
+      buf.WriteString(`\n`)
+    case '\f':
+      buf.WriteString(`\f`)
+    case '\r':
+      buf.WriteString(`\r`)
+    default:
+      if r < 0x20 || 0x7f == r {
+        fmt.Fprintf(&buf, `\u%04X`, r)
+      } else {
+        buf.WriteRune(r)
+      }
+    }
+  }
+  buf.WriteByte('"')
+  return buf.String()
+}
@@ -0,0 +1,150 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestDecodeTOML(t *testing.T) {
+  data := `# comment
+title = "TOML"
+a.b = 1
+"quoted key" = 'literal\n'
+hex = 0xff
+under = 1_000
+pi = 3.14
+inf = -inf
+on = true
+dt = 1979-05-27T07:32:00Z
+list = [1, 2, [3, 4],]
+inline = { x = 1, y.z = "w" }
+text = """
+line1 \
+  line2"""
+
+[server]
+host = "localhost" # comment
+
+[server.tls]
+enabled = false
+
+[[items]]
+name = "a"
+
+[[items]]
+name = "b"
+[items.sub]
+v = 1
+`
+  conf, err := FromData([]byte(data), "toml")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "title", expect: "TOML"},
+    {path: "a.b", expect: int64(1)},
+    {path: "quoted key", expect: `literal\n`},
+    {path: "hex", expect: int64(255)},
+    {path: "under", expect: int64(1000)},
+    {path: "pi", expect: 3.14},
+    {path: "on", expect: true},
+    {path: "dt", expect: time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)},
+    {path: "list.2.1", expect: int64(4)},
+    {path: "inline.y.z", expect: "w"},
+    {path: "text", expect: "line1 line2"},
+    {path: "server.host", expect: "localhost"},
+    {path: "server.tls.enabled", expect: false},
+    {path: "items.0.name", expect: "a"},
+    {path: "items.1.sub.v", expect: int64(1)},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+  if v, _ := conf.Get("inf"); v.(float64) > 0 {
+    t.Errorf("inf: expected -inf, got %v", v)
+  }
+}
+
+func TestDecodeTOMLErrors(t *testing.T) {
+  tests := []struct {
+    data string
+    line int
+  }{
+    {data: "a = 1\na = 2", line: 2},
+    {data: "[t]\n[t]", line: 2},
+    {data: "a = 1\nb = ", line: 2},
+    {data: "a = \"open", line: 1},
+    {data: "a = 1 b = 2", line: 1},
+    {data: "a = 1\n\n[t\n", line: 3},
+    {data: "a = 01", line: 1},
+    {data: "a.b = 1\n[a]\nb = 2", line: 2},
+  }
+  for _, test := range tests {
+    _, err := FromData([]byte(test.data), "toml")
+    var perr *ParseError
+    if !errors.As(err, &perr) {
+      t.Errorf("%q: expected ParseError, got %v", test.data, err)
+      continue
+    }
+    if test.line != perr.Line {
+      t.Errorf("%q: expected line %d, got %d (%v)", test.data, test.line, perr.Line, err)
+    }
+  }
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+  conf := Config{
+    "int":    int64(3),
+    "float":  3.,
+    "small":  float32(0.5),
+    "big":    1e21,
+    "str":    "a \"quoted\" value",
+    "list":   ConfigArr{int64(1), 2.},
+    "db":     Config{"port": int64(5432)},
+    "tables": ConfigArr{Config{"n": int64(1)}},
+  }
+  data, err := conf.Encode("toml")
+  if nil != err {
+    t.Fatal(err)
+  }
+  decoded, err := FromData(data, "toml")
+  if nil != err {
+    t.Fatalf("%s: %v", data, err)
+  }
+  conf["small"] = 0.5
+  if !reflect.DeepEqual(conf, decoded) {
+    t.Errorf("expected %#v, got %#v from\n%s", conf, decoded, data)
+  }
+}
@@ -0,0 +1,323 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "encoding/xml"
+  "fmt"
+  "io"
+  "regexp"
+  "strings"
+)
+
+// XMLOptions describes mapping of XML documents into the Config
+type XMLOptions struct {
+  // AttrPrefix is prepended to the attribute names
+  AttrPrefix string
+
+  // TextKey is the key for the text content of elements
+  // which have attributes or child elements
+  TextKey string
+
+  // Root is the document element of the config with several keys,
+  // the element with this name is unwrapped by the decoder.
+  // Empty value keeps the document element as the config key.
+  Root string
+
+  // ArrayAttr marks the element which children are the array items,
+  // e.g. <list config-array="true"><item>x</item></list>. The encoder uses it
+  // for empty, single item and nested arrays which can't be written
+  // as repeated elements. Empty value disables such arrays.
+  ArrayAttr string
+}
+
+var (
+  // XMLDefaults is used by FromData and FromFile
+  XMLDefaults = XMLOptions{AttrPrefix: "@", TextKey: "#text", Root: "config", ArrayAttr: "config-array"}
+
+  xmlNameEx = regexp.MustCompile(`^[\p{L}_:][\p{L}\p{N}_:.\-]*$`)
+)
+
+// xmlSiblings are values of the repeated elements, it differs
+// from the array value of the single element
+type xmlSiblings []interface{}
+
+type xmlNode struct {
+  name   string
+  values map[string]interface{}
+  text   bytes.Buffer
+  array  bool
+  items  []interface{}
+}
+
+// FromXML decodes XML document with the custom mapping options.
+// Elements become nested keys, repeated sibling elements become ConfigArr.
+func FromXML(data []byte, opts XMLOptions) (Config, error) {
+  info, err := decodeXML(data, opts)
+  if nil != err {
+    return nil, err
+  }
+  return From(info)
+}
+
+func decodeXML(data []byte, opts XMLOptions) (interface{}, error) {
+  var (
+    decoder = xml.NewDecoder(bytes.NewReader(data))
+    result  = make(map[string]interface{})
+    stack   = make([]*xmlNode, 0)
+  )
+
+  for {
+    token, err := decoder.Token()
+    if io.EOF == err {
+      break
+    }
+    if nil != err {
+      return nil, xmlParseError(err)
+    }
+
+    switch t := token.(type) {
+    case xml.StartElement:
+      node := &xmlNode{name: t.Name.Local, values: make(map[string]interface{})}
+      for _, attr := range t.Attr {
+        if "xmlns" == attr.Name.Space || "xmlns" == attr.Name.Local {
+          continue
+        }
+        if len(opts.ArrayAttr) > 0 && opts.ArrayAttr == attr.Name.Local && "true" == attr.Value {
+          node.array = true
+          continue
+        }
+        node.values[opts.AttrPrefix+attr.Name.Local] = attr.Value
+      }
+      stack = append(stack, node)
+      break
+    case xml.CharData:
+      if len(stack) > 0 {
+        stack[len(stack)-1].text.Write(t)
+      }
+      break
+    case xml.EndElement:
+      node := stack[len(stack)-1]
+      stack = stack[:len(stack)-1]
+      if len(stack) < 1 {
+        addXMLValue(result, node.name, node.value(opts))
+      } else if parent := stack[len(stack)-1]; parent.array {
+        parent.items = append(parent.items, node.value(opts))
+      } else {
+        addXMLValue(parent.values, node.name, node.value(opts))
+      }
+      break
+    }
+  }
+
+  result = xmlValues(result)
+  if len(opts.Root) > 0 && 1 == len(result) {
+    switch root := result[opts.Root].(type) {
+    case map[string]interface{}:
+      return root, nil
+    case string:
+      if len(root) < 1 { // Empty <config/>
+        return make(map[string]interface{}), nil
+      }
+      break
+    }
+  }
+  return result, nil
+}
+
+func (n *xmlNode) value(opts XMLOptions) interface{} {
+  if n.array {
+    if nil == n.items {
+      return []interface{}{}
+    }
+    return n.items
+  }
+  text := strings.TrimSpace(n.text.String())
+  if len(n.values) < 1 {
+    return text
+  }
+  if len(text) > 0 {
+    n.values[opts.TextKey] = text
+  }
+  return xmlValues(n.values)
+}
+
+func addXMLValue(values map[string]interface{}, key string, value interface{}) {
+  if it, ok := values[key]; ok {
+    if list, ok := it.(xmlSiblings); ok {
+      values[key] = append(list, value)
+    } else {
+      values[key] = xmlSiblings{it, value}
+    }
+  } else {
+    values[key] = value
+  }
+}
+
+// xmlValues converts repeated elements into arrays
+func xmlValues(values map[string]interface{}) map[string]interface{} {
+  for key, value := range values {
+    if list, ok := value.(xmlSiblings); ok {
+      values[key] = []interface{}(list)
+    }
+  }
+  return values
+}
+
+func xmlParseError(err error) error {
+  if serr, ok := err.(*xml.SyntaxError); ok {
+    return &ParseError{Format: "xml", Line: serr.Line, Msg: serr.Msg}
+  }
+  return err
+}
+
+// encodeXML writes the config as XML document. Config with the single key
+// is written as the root element, otherwise the root is opts.Root.
+func encodeXML(conf Config, opts XMLOptions) ([]byte, error) {
+  var (
+    buf  bytes.Buffer
+    enc  = xml.NewEncoder(&buf)
+    root = opts.Root
+    item = interface{}(conf)
+  )
+
+  if 1 == len(conf) {
+    for key, value := range conf {
+      // The key same as the root would be unwrapped by the decoder
+      if _, ok := value.(ConfigArr); !ok && key != opts.Root {
+        root, item = key, value
+      }
+    }
+  }
+  if len(root) < 1 {
+    return nil, fmt.Errorf("xml: config with %d keys needs the root element: %w", len(conf), ErrUnsupportedValue)
+  }
+
+  enc.Indent("", "  ")
+  if err := encodeXMLElement(enc, root, item, opts); nil != err {
+    return nil, err
+  }
+  if err := enc.Flush(); nil != err {
+    return nil, err
+  }
+  return buf.Bytes(), nil
+}
+
+func encodeXMLElement(enc *xml.Encoder, name string, value interface{}, opts XMLOptions) (err error) {
+  if !xmlNameEx.MatchString(name) {
+    return fmt.Errorf("xml: %q: %w", name, ErrUnsupportedValue)
+  }
+  start := xml.StartElement{Name: xml.Name{Local: name}}
+
+  switch v := value.(type) {
+  case ConfigArr:
+    if len(v) < 2 {
+      // Single item or empty array would be decoded as the value
+      return encodeXMLArray(enc, name, v, opts)
+    }
+    for _, it := range v {
+      if sub, ok := it.(ConfigArr); ok {
+        err = encodeXMLArray(enc, name, sub, opts)
+      } else {
+        err = encodeXMLElement(enc, name, it, opts)
+      }
+      if nil != err {
+        return err
+      }
+    }
+    return nil
+  case Config:
+    var (
+      text     interface{}
+      children = make([]string, 0, len(v))
+    )
+    for _, key := range sortedKeys(v) {
+      if len(opts.AttrPrefix) > 0 && strings.HasPrefix(key, opts.AttrPrefix) {
+        start.Attr = append(start.Attr, xml.Attr{
+          Name:  xml.Name{Local: key[len(opts.AttrPrefix):]},
+          Value: scalarToString(v[key]),
+        })
+      } else if key == opts.TextKey {
+        text = v[key]
+      } else {
+        children = append(children, key)
+      }
+    }
+
+    if err = enc.EncodeToken(start); nil != err {
+      return err
+    }
+    if nil != text {
+      if err = enc.EncodeToken(xml.CharData(scalarToString(text))); nil != err {
+        return err
+      }
+    }
+    for _, key := range children {
+      if err = encodeXMLElement(enc, key, v[key], opts); nil != err {
+        return err
+      }
+    }
+    break
+  default:
+    if err = enc.EncodeToken(start); nil != err {
+      return err
+    }
+    if nil != value {
+      if err = enc.EncodeToken(xml.CharData(scalarToString(value))); nil != err {
+        return err
+      }
+    }
+    break
+  }
+  return enc.EncodeToken(start.End())
+}
+
+// encodeXMLArray writes the array as the element marked by opts.ArrayAttr
+// with <item> children
+func encodeXMLArray(enc *xml.Encoder, name string, arr ConfigArr, opts XMLOptions) (err error) {
+  if len(opts.ArrayAttr) < 1 {
+    return fmt.Errorf("xml: %q: array of %d items: %w", name, len(arr), ErrUnsupportedValue)
+  }
+
+  start := xml.StartElement{
+    Name: xml.Name{Local: name},
+    Attr: []xml.Attr{{Name: xml.Name{Local: opts.ArrayAttr}, Value: "true"}},
+  }
+  if err = enc.EncodeToken(start); nil != err {
+    return err
+  }
+  for _, it := range arr {
+    if sub, ok := it.(ConfigArr); ok {
+      err = encodeXMLArray(enc, "item", sub, opts)
+    } else {
+      err = encodeXMLElement(enc, "item", it, opts)
+    }
+    if nil != err {
+      return err
+    }
+  }
+  return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,193 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+)
+
+func TestDecodeXML(t *testing.T) {
+  data := `<?xml version="1.0"?>
+<app xmlns="urn:app" name="demo">
+  <params>
+    <p1>v1</p1>
+    <p2 unit="s">10</p2>
+  </params>
+  <server>a</server>
+  <server>b</server>
+  <empty/>
+</app>`
+  conf, err := FromData([]byte(data), "xml")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    path   string
+    expect interface{}
+  }{
+    {path: "app.@name", expect: "demo"},
+    {path: "app.params.p1", expect: "v1"},
+    {path: "app.params.p2.@unit", expect: "s"},
+    {path: "app.params.p2.#text", expect: "10"},
+    {path: "app.server", expect: ConfigArr{"a", "b"}},
+    {path: "app.server.1", expect: "b"},
+    {path: "app.empty", expect: ""},
+  }
+  for _, test := range tests {
+    if v, err := conf.Get(test.path); nil != err || !reflect.DeepEqual(test.expect, v) {
+      t.Errorf("%s: expected %#v, got %#v (%v)", test.path, test.expect, v, err)
+    }
+  }
+  if _, ok := conf.GetDefault("app", nil).(Config)["@xmlns"]; ok {
+    t.Error("namespace declarations must be skipped")
+  }
+
+  conf, err = FromXML([]byte(`<app><p a="1">text</p></app>`), XMLOptions{AttrPrefix: "attr_", TextKey: "value"})
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"app": Config{"p": Config{"attr_a": "1", "value": "text"}}}); !reflect.DeepEqual(expect, conf) {
+    t.Errorf("custom options: expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestDecodeXMLConfigRoot(t *testing.T) {
+  data := []byte(`<config><db host="localhost"/><debug>true</debug></config>`)
+
+  // The default root element is unwrapped
+  conf, err := FromData(data, "xml")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if v, _ := conf.Get("db.@host"); "localhost" != v {
+    t.Errorf("db.@host: expected localhost, got %#v", v)
+  }
+  if "true" != conf.String("debug") {
+    t.Errorf("debug: expected true, got %#v", conf["debug"])
+  }
+
+  // Other root elements are kept
+  conf, err = FromData([]byte(`<settings><debug>true</debug></settings>`), "xml")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if "true" != conf.String("settings.debug") {
+    t.Errorf("settings.debug: expected true, got %#v", conf)
+  }
+
+  // <config> with the text is not unwrapped
+  conf, err = FromData([]byte(`<config>text</config>`), "xml")
+  if nil != err || "text" != conf.String("config") {
+    t.Errorf("config text: expected text, got %#v (%v)", conf, err)
+  }
+}
+
+func TestDecodeXMLError(t *testing.T) {
+  _, err := FromData([]byte("<app>\n  <a>1</b>\n</app>"), "xml")
+  var perr *ParseError
+  if !errors.As(err, &perr) || 2 != perr.Line {
+    t.Errorf("expected ParseError at line 2, got %v", err)
+  }
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+  tests := []Config{
+    {"a": "1", "b": Config{"c": "2"}},
+    {"db": Config{"host": "localhost", "@port": "5432"}},
+    {"list": ConfigArr{"x", "y"}},
+    {"list": ConfigArr{"x"}, "b": "1"},
+    {"list": ConfigArr{}, "b": "1"},
+    {"list": ConfigArr{ConfigArr{"a", "b"}, ConfigArr{"c"}, "d"}},
+    {"list": ConfigArr{ConfigArr{}}},
+    {"servers": ConfigArr{Config{"host": "a", "@port": "80"}}},
+    {"servers": ConfigArr{Config{"host": "a"}, Config{"tags": ConfigArr{"x"}}}},
+    {"config": Config{"a": "1"}},
+    {"config": Config{"a": "1"}, "b": "2"},
+    {},
+  }
+  for _, conf := range tests {
+    data, err := conf.Encode("xml")
+    if nil != err {
+      t.Errorf("%#v: %v", conf, err)
+      continue
+    }
+    decoded, err := FromData(data, "xml")
+    if nil != err {
+      t.Errorf("%s: %v", data, err)
+      continue
+    }
+    if !reflect.DeepEqual(conf, decoded) {
+      t.Errorf("%#v: decoded %#v from %s", conf, decoded, data)
+    }
+  }
+}
+
+func TestXMLRoot(t *testing.T) {
+  data := []byte(`<config><a>1</a></config>`)
+
+  conf, err := FromXML(data, XMLOptions{AttrPrefix: "@", TextKey: "#text"})
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"config": Config{"a": "1"}}); !reflect.DeepEqual(expect, conf) {
+    t.Errorf("empty root: expected %#v, got %#v", expect, conf)
+  }
+
+  opts := XMLDefaults
+  opts.Root = "settings"
+  if _, err = encodeXML(Config{"a": "1", "b": "2"}, opts); nil != err {
+    t.Error(err)
+  }
+  opts.Root = ""
+  if _, err = encodeXML(Config{"a": "1", "b": "2"}, opts); nil == err {
+    t.Error("empty root: expected error for several keys")
+  }
+}
+
+func TestXMLArrayAttr(t *testing.T) {
+  opts := XMLDefaults
+  opts.ArrayAttr = ""
+  for _, conf := range []Config{
+    {"list": ConfigArr{"x"}},
+    {"list": ConfigArr{}},
+    {"list": ConfigArr{ConfigArr{"a", "b"}, "c"}},
+  } {
+    if data, err := encodeXML(conf, opts); !errors.Is(err, ErrUnsupportedValue) {
+      t.Errorf("%#v: expected ErrUnsupportedValue, got %s (%v)", conf, data, err)
+    }
+  }
+
+  // Repeated elements are used for the plain arrays
+  data, err := Config{"a": ConfigArr{"x", "y"}, "b": "1"}.Encode("xml")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := "<config>\n  <a>x</a>\n  <a>y</a>\n  <b>1</b>\n</config>"; expect != string(data) {
+    t.Errorf("expected %q, got %q", expect, data)
+  }
+}
@@ -25,8 +25,23 @@
 package config
 
 import (
+  "errors"
+  "fmt"
+  "math"
   "reflect"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
   "unicode"
+
+  "github.com/demdxx/gocast"
+)
+
+var (
+  coerceIntEx   = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)$`)
+  coerceFloatEx = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
 )
 
 func prepareValueForSet(value interface{}) interface{} {
@@ -46,8 +61,164 @@ func prepareValueForSet(value interface{}) interface{} {
   return value
 }
 
-func isArrayChain(ch string) bool {
-  return "*" == ch || "+" == ch || isDigit(ch)
+func fromMap(c interface{}, path string) (Config, error) {
+  if nil == c {
+    return make(Config), nil
+  }
+
+  v := reflect.ValueOf(c)
+  if reflect.Struct == v.Kind() || (reflect.Ptr == v.Kind() && !v.IsNil() && reflect.Struct == v.Elem().Kind()) {
+    return fromStruct(reflect.Indirect(v), path)
+  }
+  if reflect.Map != v.Kind() {
+    sm, err := gocast.ToSiMap(c, "field", true)
+    if nil != err {
+      return nil, err
+    }
+    v = reflect.ValueOf(sm)
+  }
+
+  var (
+    conf = make(Config, v.Len())
+    orig = make(map[string]interface{}, v.Len())
+  )
+  for _, k := range v.MapKeys() {
+    key, err := keyToString(k.Interface())
+    if nil != err {
+      return nil, &KeyError{Path: path, Key: k.Interface(), Msg: err.Error()}
+    }
+    if prev, ok := orig[key]; ok {
+      // Report the converted key, the order of map keys is random
+      dup := k.Interface()
+      if _, ok := dup.(string); ok {
+        dup = prev
+      }
+      return nil, &KeyError{Path: path, Key: dup, Msg: "duplicate key " + strconv.Quote(key)}
+    }
+    orig[key] = k.Interface()
+
+    if conf[key], err = fromItem(v.MapIndex(k).Interface(), joinPath(path, key)); nil != err {
+      return nil, err
+    }
+  }
+  return conf, nil
+}
+
+func fromSlice(c interface{}, path string) (ConfigArr, error) {
+  if nil == c {
+    return make(ConfigArr, 0), nil
+  }
+
+  v := reflect.ValueOf(c)
+  if reflect.Slice != v.Kind() && reflect.Array != v.Kind() {
+    return nil, ErrNoValid
+  }
+
+  var (
+    err  error
+    conf = make(ConfigArr, v.Len())
+  )
+  for i := range conf {
+    if conf[i], err = fromItem(v.Index(i).Interface(), joinPath(path, strconv.Itoa(i))); nil != err {
+      return nil, err
+    }
+  }
+  return conf, nil
+}
+
+func fromItem(value interface{}, path string) (interface{}, error) {
+  switch value.(type) {
+  case nil:
+    return nil, nil
+  case string, bool, int, int64, float64, time.Time: // Values of the decoders
+    return value, nil
+  }
+  return fromValue(reflect.ValueOf(value), path)
+}
+
+// keyToString converts scalar map key into the string,
+// integral floats are written as integers
+func keyToString(key interface{}) (string, error) {
+  switch k := key.(type) {
+  case nil:
+    return "", errors.New("null key")
+  case string:
+    return k, nil
+  case bool:
+    return strconv.FormatBool(k), nil
+  case time.Time:
+    return k.Format(time.RFC3339Nano), nil
+  }
+
+  switch v := reflect.ValueOf(key); v.Kind() {
+  case reflect.String:
+    return v.String(), nil
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    return strconv.FormatInt(v.Int(), 10), nil
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+    return strconv.FormatUint(v.Uint(), 10), nil
+  case reflect.Float32, reflect.Float64:
+    return scalarToString(v.Float()), nil
+  case reflect.Bool:
+    return strconv.FormatBool(v.Bool()), nil
+  }
+  if s, ok := key.(fmt.Stringer); ok {
+    return s.String(), nil
+  }
+  return "", fmt.Errorf("unsupported key type %T", key)
+}
+
+func joinPath(path, key string) string {
+  if len(path) < 1 {
+    return key
+  }
+  return path + "." + key
+}
+
+// setItem sets the value by the path inside of the item
+// replacing the item if it's not suitable for the path.
+// Digit key creates the array only for the first index, other digit keys
+// are the object keys, so the values are never dropped.
+func setItem(it interface{}, path []string, value interface{}) interface{} {
+  if len(path) < 1 {
+    return prepareValueForSet(value)
+  }
+
+  switch a := it.(type) {
+  case Config:
+    if !isArrayOperator(path[0]) {
+      return a.SetPath(path, value)
+    }
+    break
+  case ConfigArr:
+    // Index out of the range is ignored, the array keeps its type
+    if isDigit(path[0]) || isArrayOperator(path[0]) {
+      return a.SetPath(path, value)
+    }
+    break
+  }
+
+  if "$" == path[0] || "*" == path[0] {
+    return it // No items to set
+  }
+  if "+" == path[0] || "0" == path[0] {
+    return make(ConfigArr, 0).SetPath(path, value)
+  }
+  return make(Config).SetPath(path, value)
+}
+
+func copyItem(it interface{}) interface{} {
+  switch a := it.(type) {
+  case Config:
+    return a.Copy()
+  case ConfigArr:
+    return a.Copy()
+  }
+  return it
+}
+
+func isArrayOperator(ch string) bool {
+  return "*" == ch || "+" == ch || "$" == ch
 }
 
 func isDigit(s string) bool {
@@ -61,3 +232,109 @@ func isDigit(s string) bool {
   }
   return true
 }
+
+func sortedKeys(conf Config) []string {
+  keys := make([]string, 0, len(conf))
+  for k := range conf {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+  return keys
+}
+
+// scalarToString converts value for the text formats,
+// integral floats (e.g. numbers from JSON) are written as integers
+func scalarToString(value interface{}) string {
+  switch v := value.(type) {
+  case nil:
+    return ""
+  case string:
+    return v
+  case time.Time:
+    return v.Format(time.RFC3339Nano)
+  case float64:
+    if isIntegral(v) {
+      return strconv.FormatInt(int64(v), 10)
+    }
+    return strconv.FormatFloat(v, 'g', -1, 64)
+  case float32:
+    if isIntegral(float64(v)) {
+      return strconv.FormatInt(int64(v), 10)
+    }
+    return strconv.FormatFloat(float64(v), 'g', -1, 32)
+  }
+  return gocast.ToString(value)
+}
+
+func isIntegral(v float64) bool {
+  return v == math.Trunc(v) && math.Abs(v) < 1<<53
+}
+
+// coerceString converts numeric and boolean strings into the values,
+// numbers with leading zeros are kept as strings
+func coerceString(s string) interface{} {
+  switch strings.ToLower(s) {
+  case "true":
+    return true
+  case "false":
+    return false
+  }
+  if coerceIntEx.MatchString(s) {
+    if v, err := strconv.ParseInt(s, 10, 64); nil == err {
+      return v
+    }
+  }
+  if coerceFloatEx.MatchString(s) {
+    if v, err := strconv.ParseFloat(s, 64); nil == err {
+      return v
+    }
+  }
+  return s
+}
+
+// keyValue is the string value of the flat source like environment variables
+// or key-value store, key is the original name of the value
+type keyValue struct {
+  key   string
+  path  []string
+  value string
+}
+
+// setPathValues sets the values in the order of paths and writes keys
+// of the values into the origins if it's not nil
+func (conf Config) setPathValues(values []keyValue, coerce bool, origins Origins) Config {
+  // Array items must be set in the order of indexes
+  sort.SliceStable(values, func(i, j int) bool {
+    return lessPath(values[i].path, values[j].path)
+  })
+
+  for _, v := range values {
+    if coerce {
+      conf.SetPath(v.path, coerceString(v.value))
+    } else {
+      conf.SetPath(v.path, v.value)
+    }
+    if nil != origins {
+      origins[strings.Join(v.path, ".")] = Origin{Key: v.key}
+    }
+  }
+  return conf
+}
+
+// lessPath compares paths with the numeric order of digit keys
+func lessPath(a, b []string) bool {
+  for i := 0; i < len(a) && i < len(b); i++ {
+    if a[i] == b[i] {
+      continue
+    }
+    if isDigit(a[i]) && isDigit(b[i]) {
+      ai, _ := strconv.Atoi(a[i])
+      bi, _ := strconv.Atoi(b[i])
+      if ai != bi {
+        return ai < bi
+      }
+    }
+    return a[i] < b[i]
+  }
+  return len(a) < len(b)
+}
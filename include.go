@@ -0,0 +1,288 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "fmt"
+  "io/fs"
+  "io/ioutil"
+  "os"
+  "path"
+  "path/filepath"
+  "strings"
+)
+
+// Directives of the config files
+const (
+  ExtendsKey = "$extends"
+  IncludeKey = "$include"
+)
+
+// fileLoader loads files with the directives,
+// chain of the loading files is used to detect cycles
+type fileLoader struct {
+  fsys  fs.FS // nil means the OS file system
+  chain []string
+}
+
+func newFileLoader() *fileLoader {
+  return &fileLoader{}
+}
+
+func newFSLoader(fsys fs.FS) *fileLoader {
+  return &fileLoader{fsys: fsys}
+}
+
+func (l *fileLoader) load(filename, ftype string) (Config, Origins, error) {
+  abs, err := l.abs(filename)
+  if nil != err {
+    return nil, nil, err
+  }
+  if hasString(l.chain, abs) {
+    return nil, nil, fmt.Errorf("%w: %s -> %s", ErrIncludeCycle, strings.Join(l.chain, " -> "), abs)
+  }
+
+  data, err := l.readFile(filename)
+  if nil != err {
+    return nil, nil, err
+  }
+  if "" == ftype {
+    ftype = FormatByExtension(filename)
+  }
+
+  conf, origins, err := decodeOrigins(data, ftype)
+  if nil != err {
+    return nil, nil, err
+  }
+  for path, o := range origins {
+    o.File = filename
+    origins[path] = o
+  }
+
+  extends, err := directiveFiles(conf, ExtendsKey)
+  if nil != err {
+    return nil, nil, err
+  }
+  includes, err := directiveFiles(conf, IncludeKey)
+  if nil != err {
+    return nil, nil, err
+  }
+  if len(extends) < 1 && len(includes) < 1 {
+    return conf, origins, nil
+  }
+
+  l.chain = append(l.chain, abs)
+  defer func() { l.chain = l.chain[:len(l.chain)-1] }()
+
+  var (
+    dir    = l.dir(filename)
+    result = make(Config)
+    merged = make(Origins)
+  )
+  if err = l.loadAll(result, merged, dir, extends); nil != err {
+    return nil, nil, err
+  }
+  mergeOrigins(result, merged, conf, origins)
+  if err = l.loadAll(result, merged, dir, includes); nil != err {
+    return nil, nil, err
+  }
+  return result, merged, nil
+}
+
+// loadAll merges files matching the patterns into the config
+func (l *fileLoader) loadAll(conf Config, origins Origins, dir string, patterns []string) error {
+  for _, pattern := range patterns {
+    pattern = l.join(dir, pattern)
+
+    files := []string{pattern}
+    if hasGlobMeta(pattern) {
+      var err error
+      if files, err = l.glob(pattern); nil != err {
+        return fmt.Errorf("%s: %w", pattern, err)
+      }
+    }
+
+    for _, filename := range files {
+      c, list, err := l.load(filename, "")
+      if nil != err {
+        return fmt.Errorf("%s: %w", filename, err)
+      }
+      mergeOrigins(conf, origins, c, list)
+    }
+  }
+  return nil
+}
+
+// files returns the sorted list of the files of the directory matching
+// the pattern, empty pattern means all files of the registered formats
+func (l *fileLoader) files(dir, pattern string) ([]string, error) {
+  info, err := l.stat(dir)
+  if nil != err {
+    return nil, err
+  }
+  if !info.IsDir() {
+    return nil, fmt.Errorf("%s: not a directory", dir)
+  }
+
+  all := "" == pattern
+  if all {
+    pattern = "*"
+  }
+
+  // Glob returns files in the lexical order
+  matches, err := l.glob(l.join(dir, pattern))
+  if nil != err {
+    return nil, err
+  }
+
+  files := make([]string, 0, len(matches))
+  for _, filename := range matches {
+    if all && "" == FormatByExtension(filename) {
+      continue
+    }
+    if info, err := l.stat(filename); nil != err || info.IsDir() {
+      continue
+    }
+    files = append(files, filename)
+  }
+  return files, nil
+}
+
+func (l *fileLoader) readFile(filename string) ([]byte, error) {
+  if nil == l.fsys {
+    return ioutil.ReadFile(filename)
+  }
+  return fs.ReadFile(l.fsys, filename)
+}
+
+func (l *fileLoader) stat(filename string) (fs.FileInfo, error) {
+  if nil == l.fsys {
+    return os.Stat(filename)
+  }
+  return fs.Stat(l.fsys, filename)
+}
+
+func (l *fileLoader) glob(pattern string) ([]string, error) {
+  if nil == l.fsys {
+    return filepath.Glob(pattern)
+  }
+  return fs.Glob(l.fsys, pattern)
+}
+
+// abs returns the unique name of the file
+func (l *fileLoader) abs(filename string) (string, error) {
+  if nil == l.fsys {
+    return filepath.Abs(filename)
+  }
+  return path.Clean(filename), nil
+}
+
+func (l *fileLoader) dir(filename string) string {
+  if nil == l.fsys {
+    return filepath.Dir(filename)
+  }
+  return path.Dir(filename)
+}
+
+// join returns the name relative to the directory, names of FS
+// starting with / are relative to the root of FS
+func (l *fileLoader) join(dir, name string) string {
+  if nil == l.fsys {
+    if filepath.IsAbs(name) {
+      return name
+    }
+    return filepath.Join(dir, name)
+  }
+  if strings.HasPrefix(name, "/") {
+    return path.Clean(name[1:])
+  }
+  return path.Join(dir, name)
+}
+
+// decodeOrigins decodes the data with lines of the values if the format supports it,
+// origins are set for each leaf value
+func decodeOrigins(data []byte, ftype string) (conf Config, origins Origins, err error) {
+  lines := Origins{}
+  if codec, ok := FormatCodec(ftype).(LineCodec); ok {
+    var (
+      info interface{}
+      list map[string]int
+    )
+    if info, list, err = codec.DecodeLines(data); nil != err {
+      return nil, nil, err
+    }
+    if conf, err = From(info); nil != err {
+      return nil, nil, err
+    }
+    for path, line := range list {
+      lines[path] = Origin{Line: line}
+    }
+  } else if conf, err = FromData(data, ftype); nil != err {
+    return nil, nil, err
+  }
+
+  origins = make(Origins)
+  walkLeaves(conf, "", func(path string, _ interface{}) {
+    o, ok := lines.leafOrigin(path)
+    if !ok {
+      // Values of the inline tables and flow maps have the line of the key
+      o, _ = lines.lookup(path)
+    }
+    origins[path] = o
+  })
+  return conf, origins, nil
+}
+
+// directiveFiles removes the directive from the config
+// and returns the list of file patterns
+func directiveFiles(conf Config, key string) ([]string, error) {
+  value, ok := conf[key]
+  if !ok {
+    return nil, nil
+  }
+  delete(conf, key)
+
+  switch v := value.(type) {
+  case nil:
+    return nil, nil
+  case string:
+    return []string{v}, nil
+  case ConfigArr:
+    files := make([]string, 0, len(v))
+    for _, it := range v {
+      s, ok := it.(string)
+      if !ok {
+        return nil, fmt.Errorf("%s must be a file name or list of file names", key)
+      }
+      files = append(files, s)
+    }
+    return files, nil
+  }
+  return nil, fmt.Errorf("%s must be a file name or list of file names", key)
+}
+
+func hasGlobMeta(pattern string) bool {
+  return strings.ContainsAny(pattern, "*?[")
+}
@@ -0,0 +1,147 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "os"
+  "path/filepath"
+  "reflect"
+  "regexp"
+  "testing"
+  "testing/fstest"
+)
+
+func TestIncludeDirectives(t *testing.T) {
+  dir := t.TempDir()
+  writeTestFiles(t, dir, map[string]string{
+    "app.yaml": "$extends: base/base.yaml\n" +
+      "$include: [conf.d/*.yaml, local.toml]\n" +
+      "name: app\n" +
+      "db:\n  host: app\n",
+    "base/base.yaml":   "$extends: common.json\nname: base\nbase: true\ndb:\n  host: base\n  port: 1\n",
+    "base/common.json": `{"name": "common", "common": true}`,
+    "conf.d/20-b.yaml": "db:\n  port: 3\n",
+    "conf.d/10-a.yaml": "db:\n  port: 2\n  user: a\n",
+    "local.toml":       "debug = true\n",
+  })
+
+  conf, err := FromFile(filepath.Join(dir, "app.yaml"), "")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  // $extends files are overridden by the file, $include files override it in order
+  expect := Config{
+    "name":   "app",
+    "common": true,
+    "base":   true,
+    "db":     Config{"host": "app", "port": 3, "user": "a"},
+    "debug":  true,
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestIncludeAbsoluteAndShared(t *testing.T) {
+  dir := t.TempDir()
+  shared := filepath.Join(dir, "shared.yaml")
+  writeTestFiles(t, dir, map[string]string{
+    "shared.yaml": "shared: true\n",
+    "a/a.yaml":    "$include: [" + shared + ", ../b.yaml]\na: true\n",
+    "b.yaml":      "$include: shared.yaml\nb: true\n",
+  })
+
+  // The same file may be included several times if it's not a cycle
+  conf, err := FromFile(filepath.Join(dir, "a", "a.yaml"), "")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"a": true, "b": true, "shared": true}); !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestIncludeCycle(t *testing.T) {
+  dir := t.TempDir()
+  writeTestFiles(t, dir, map[string]string{
+    "a.yaml":    "$include: b.yaml\na: 1\n",
+    "b.yaml":    "$extends: c.yaml\nb: 1\n",
+    "c.yaml":    "$include: a.yaml\nc: 1\n",
+    "self.yaml": "$include: self.yaml\n",
+  })
+
+  _, err := FromFile(filepath.Join(dir, "a.yaml"), "")
+  if !errors.Is(err, ErrIncludeCycle) {
+    t.Fatalf("expected ErrIncludeCycle, got %v", err)
+  }
+  if !regexp.MustCompile(`Include cycle: \S*a\.yaml -> \S*b\.yaml -> \S*c\.yaml -> \S*a\.yaml`).MatchString(err.Error()) {
+    t.Errorf("expected the chain of files in %q", err)
+  }
+
+  if _, err = FromFile(filepath.Join(dir, "self.yaml"), ""); !errors.Is(err, ErrIncludeCycle) {
+    t.Errorf("self: expected ErrIncludeCycle, got %v", err)
+  }
+}
+
+func TestIncludeErrors(t *testing.T) {
+  dir := t.TempDir()
+  writeTestFiles(t, dir, map[string]string{
+    "missing.yaml": "$include: none.yaml\n",
+    "invalid.yaml": "$include: {file: a.yaml}\n",
+    "list.yaml":    "$extends: [1]\n",
+    "glob.yaml":    "$include: none/*.yaml\na: 1\n",
+  })
+
+  if _, err := FromFile(filepath.Join(dir, "missing.yaml"), ""); !errors.Is(err, os.ErrNotExist) {
+    t.Errorf("missing: expected ErrNotExist, got %v", err)
+  }
+  for _, name := range []string{"invalid.yaml", "list.yaml"} {
+    if _, err := FromFile(filepath.Join(dir, name), ""); nil == err {
+      t.Errorf("%s: expected invalid directive error", name)
+    }
+  }
+
+  // Glob without matches is empty
+  if conf, err := FromFile(filepath.Join(dir, "glob.yaml"), ""); nil != err || 1 != len(conf) {
+    t.Errorf("glob: expected the file values, got %#v (%v)", conf, err)
+  }
+}
+
+func TestIncludeFS(t *testing.T) {
+  fsys := fstest.MapFS{
+    "app/app.yaml":   {Data: []byte("$extends: /base.yaml\n$include: local.yaml\nname: app\n")},
+    "app/local.yaml": {Data: []byte("debug: true\n")},
+    "base.yaml":      {Data: []byte("name: base\nport: 80\n")},
+  }
+  conf, err := FromFS(fsys, "app/app.yaml", "")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if expect := (Config{"name": "app", "port": 80, "debug": true}); !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
@@ -0,0 +1,123 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+)
+
+// KeyPerFileOptions describes mapping of the directory files into the Config
+type KeyPerFileOptions struct {
+  // Nested turns subdirectories into nested keys
+  Nested bool
+
+  // Dotted splits file names like db.host into nested keys
+  Dotted bool
+
+  // Coerce turns file contents like "8080" or "true" into numbers and booleans
+  Coerce bool
+}
+
+// keyPerFileRetries is the count of reading attempts
+// when the directory is swapped during the reading
+const keyPerFileRetries = 3
+
+// FromKeyPerFileDir loads directory with one file per key like mounted Kubernetes
+// ConfigMap or Docker secrets (/run/secrets). The file name is the key, the content
+// without trailing newlines is the value. Hidden files are skipped.
+//
+// Kubernetes updates mounted directory by the atomic swap of the ..data symlink,
+// all files are read from the single resolved version of the directory,
+// so the config never mixes the old and the new values.
+func FromKeyPerFileDir(dir string, opts KeyPerFileOptions) (conf Config, err error) {
+  for i := 0; i < keyPerFileRetries; i++ {
+    // The old version is removed after the swap, read the new one
+    if conf, err = readKeyPerFileDir(dir, opts); nil == err || !os.IsNotExist(err) {
+      break
+    }
+  }
+  return
+}
+
+func readKeyPerFileDir(dir string, opts KeyPerFileOptions) (Config, error) {
+  root := dir
+  if data, err := filepath.EvalSymlinks(filepath.Join(dir, "..data")); nil == err {
+    root = data
+  } else if !os.IsNotExist(err) {
+    return nil, err
+  }
+
+  values, err := readKeyPerFileItems(nil, root, nil, opts)
+  if nil != err {
+    return nil, err
+  }
+  return make(Config).setPathValues(values, opts.Coerce, nil), nil
+}
+
+func readKeyPerFileItems(values []keyValue, dir string, path []string, opts KeyPerFileOptions) ([]keyValue, error) {
+  files, err := ioutil.ReadDir(dir)
+  if nil != err {
+    return nil, err
+  }
+
+  for _, file := range files {
+    name := file.Name()
+    if strings.HasPrefix(name, ".") {
+      continue // Hidden files and ..data versions
+    }
+
+    filename := filepath.Join(dir, name)
+    info, err := os.Stat(filename) // Follow the symlinks
+    if nil != err {
+      return nil, err
+    }
+
+    keys := []string{name}
+    if opts.Dotted {
+      keys = strings.Split(name, ".")
+    }
+    keyPath := append(append([]string{}, path...), keys...)
+
+    if info.IsDir() {
+      if opts.Nested {
+        if values, err = readKeyPerFileItems(values, filename, keyPath, opts); nil != err {
+          return nil, err
+        }
+      }
+      continue
+    }
+
+    data, err := ioutil.ReadFile(filename)
+    if nil != err {
+      return nil, err
+    }
+    value := strings.TrimRight(string(data), "\r\n")
+    values = append(values, keyValue{key: filename, path: keyPath, value: value})
+  }
+  return values, nil
+}
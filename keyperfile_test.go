@@ -0,0 +1,160 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "os"
+  "path/filepath"
+  "reflect"
+  "testing"
+)
+
+// writeKeyPerFileVersion writes the files into the new version directory
+// and swaps the ..data symlink to it like Kubernetes does
+func writeKeyPerFileVersion(t *testing.T, dir, version string, files map[string]string) {
+  t.Helper()
+  writeTestFiles(t, filepath.Join(dir, version), files)
+
+  tmp := filepath.Join(dir, "..data_tmp")
+  if err := os.Symlink(version, tmp); nil != err {
+    t.Skip("symlinks are not supported:", err)
+  }
+  if err := os.Rename(tmp, filepath.Join(dir, "..data")); nil != err {
+    t.Fatal(err)
+  }
+  for name := range files {
+    link := filepath.Join(dir, name)
+    if _, err := os.Lstat(link); nil == err {
+      continue
+    }
+    if err := os.Symlink(filepath.Join("..data", name), link); nil != err {
+      t.Fatal(err)
+    }
+  }
+}
+
+func TestFromKeyPerFileDir(t *testing.T) {
+  dir := t.TempDir()
+  writeTestFiles(t, dir, map[string]string{
+    "host":        "localhost\n",
+    "port":        "5432\r\n",
+    "db.name":     "app",
+    ".hidden":     "skipped",
+    "tls/enabled": "true",
+  })
+
+  tests := []struct {
+    name   string
+    opts   KeyPerFileOptions
+    expect Config
+  }{
+    {
+      name:   "plain",
+      expect: Config{"host": "localhost", "port": "5432", "db.name": "app"},
+    },
+    {
+      name:   "dotted",
+      opts:   KeyPerFileOptions{Dotted: true},
+      expect: Config{"host": "localhost", "port": "5432", "db": Config{"name": "app"}},
+    },
+    {
+      name: "nested coerce",
+      opts: KeyPerFileOptions{Nested: true, Coerce: true},
+      expect: Config{
+        "host":    "localhost",
+        "port":    int64(5432),
+        "db.name": "app",
+        "tls":     Config{"enabled": true},
+      },
+    },
+  }
+  for _, test := range tests {
+    conf, err := FromKeyPerFileDir(dir, test.opts)
+    if nil != err {
+      t.Fatalf("%s: %v", test.name, err)
+    }
+    if !reflect.DeepEqual(test.expect, conf) {
+      t.Errorf("%s: expected %#v, got %#v", test.name, test.expect, conf)
+    }
+  }
+}
+
+func TestFromKeyPerFileDirArrays(t *testing.T) {
+  dir := t.TempDir()
+  // Directory order of the files is "0", "1", "10", "2", ...
+  files := map[string]string{
+    "servers/0": "a", "servers/1": "b", "servers/2": "c", "servers/10": "k",
+    "servers/3": "d", "servers/4": "e", "servers/5": "f", "servers/6": "g",
+    "servers/7": "h", "servers/8": "i", "servers/9": "j",
+  }
+  writeTestFiles(t, dir, files)
+
+  conf, err := FromKeyPerFileDir(dir, KeyPerFileOptions{Nested: true})
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{"servers": ConfigArr{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}}
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestFromKeyPerFileDirDataLink(t *testing.T) {
+  dir := t.TempDir()
+  writeKeyPerFileVersion(t, dir, "..2026_10_01_00_00_00.1", map[string]string{
+    "host": "old",
+    "port": "1",
+  })
+  writeKeyPerFileVersion(t, dir, "..2026_10_02_00_00_00.2", map[string]string{
+    "host": "new",
+    "port": "2",
+  })
+  writeTestFiles(t, dir, map[string]string{"stale": "not in the data"})
+
+  conf, err := FromKeyPerFileDir(dir, KeyPerFileOptions{})
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{"host": "new", "port": "2"}
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+}
+
+func TestFromKeyPerFileDirErrors(t *testing.T) {
+  if _, err := FromKeyPerFileDir(filepath.Join(t.TempDir(), "missing"), KeyPerFileOptions{}); !os.IsNotExist(err) {
+    t.Errorf("missing: expected not exist error, got %v", err)
+  }
+
+  // The dangling file is never fixed by the retries
+  dir := t.TempDir()
+  writeKeyPerFileVersion(t, dir, "..2026_10_01_00_00_00.1", map[string]string{"host": "localhost"})
+  if err := os.Symlink("missing", filepath.Join(dir, "..2026_10_01_00_00_00.1", "port")); nil != err {
+    t.Fatal(err)
+  }
+  if _, err := FromKeyPerFileDir(dir, KeyPerFileOptions{}); !os.IsNotExist(err) {
+    t.Errorf("dangling: expected not exist error, got %v", err)
+  }
+}
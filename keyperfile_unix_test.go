@@ -0,0 +1,85 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+//go:build !windows
+// +build !windows
+
+package config
+
+import (
+  "os"
+  "path/filepath"
+  "reflect"
+  "syscall"
+  "testing"
+)
+
+// TestFromKeyPerFileDirSwap swaps the ..data symlink while the old version
+// is read, the FIFO file blocks the reading until the swap is done
+func TestFromKeyPerFileDirSwap(t *testing.T) {
+  dir := t.TempDir()
+  oldVersion := "..2026_10_01_00_00_00.1"
+  writeKeyPerFileVersion(t, dir, oldVersion, map[string]string{"b": "old"})
+  fifo := filepath.Join(dir, oldVersion, "a")
+  if err := syscall.Mkfifo(fifo, 0644); nil != err {
+    t.Skip("fifo is not supported:", err)
+  }
+  newVersion := "..2026_10_02_00_00_00.2"
+  writeTestFiles(t, filepath.Join(dir, newVersion), map[string]string{"a": "new", "b": "new"})
+
+  done := make(chan error, 1)
+  go func() {
+    // Opening for writing waits for the reader of the old version
+    w, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+    if nil != err {
+      done <- err
+      return
+    }
+    tmp := filepath.Join(dir, "..data_tmp")
+    if err = os.Symlink(newVersion, tmp); nil == err {
+      if err = os.Rename(tmp, filepath.Join(dir, "..data")); nil == err {
+        err = os.RemoveAll(filepath.Join(dir, oldVersion))
+      }
+    }
+    if _, werr := w.Write([]byte("old")); nil == err {
+      err = werr
+    }
+    if cerr := w.Close(); nil == err {
+      err = cerr
+    }
+    done <- err
+  }()
+
+  conf, err := FromKeyPerFileDir(dir, KeyPerFileOptions{})
+  if err := <-done; nil != err {
+    t.Fatal(err)
+  }
+  if nil != err {
+    t.Fatal(err)
+  }
+  expect := Config{"a": "new", "b": "new"}
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected the new version %#v, got %#v", expect, conf)
+  }
+}
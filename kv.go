@@ -0,0 +1,299 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "context"
+  "sort"
+  "strings"
+  "sync"
+)
+
+// KVPair is the value of the key-value store
+type KVPair struct {
+  Key   string
+  Value string
+}
+
+// KVEvent describes change of the key
+type KVEvent struct {
+  Key     string
+  Value   string
+  Deleted bool
+}
+
+// KVSource is the key-value store with hierarchical keys like /app/db/host
+// (etcd, Consul, etc.)
+type KVSource interface {
+  // List returns all pairs with the key prefix
+  List(ctx context.Context, prefix string) ([]KVPair, error)
+
+  // Get returns value of the key or ErrNoValue
+  Get(ctx context.Context, key string) (string, error)
+
+  // Watch sends changes of the keys with the prefix until the context is done,
+  // the channel is closed after that
+  Watch(ctx context.Context, prefix string) (<-chan KVEvent, error)
+}
+
+// KVOptions describes mapping of the keys into the Config
+type KVOptions struct {
+  // Separator of the nested keys, "/" by default.
+  // Digit keys are array indexes: /app/servers/0/host is servers.0.host
+  Separator string
+
+  // Coerce turns stored values like "8080" or "true" into numbers and booleans
+  Coerce bool
+}
+
+// FromKV loads keys with the prefix, the prefix is removed from the keys
+func FromKV(ctx context.Context, src KVSource, prefix string, opts KVOptions) (Config, error) {
+  pairs, err := src.List(ctx, prefix)
+  if nil != err {
+    return nil, err
+  }
+  values := make(map[string]string, len(pairs))
+  for _, p := range pairs {
+    values[p.Key] = p.Value
+  }
+  return kvConfig(values, prefix, opts), nil
+}
+
+// WatchKV sends the config of the keys with the prefix on each change,
+// the first value is the current config. The channel is closed when
+// the context is done or the source stops watching.
+func WatchKV(ctx context.Context, src KVSource, prefix string, opts KVOptions) (<-chan Config, error) {
+  ctx, cancel := context.WithCancel(ctx)
+
+  // Watch before List to not miss changes between them
+  events, err := src.Watch(ctx, prefix)
+  if nil != err {
+    cancel()
+    return nil, err
+  }
+  pairs, err := src.List(ctx, prefix)
+  if nil != err {
+    cancel()
+    return nil, err
+  }
+
+  values := make(map[string]string, len(pairs))
+  for _, p := range pairs {
+    values[p.Key] = p.Value
+  }
+
+  updates := make(chan Config, 1)
+  go func() {
+    defer close(updates)
+    defer cancel()
+    for {
+      select {
+      case updates <- kvConfig(values, prefix, opts):
+      case <-ctx.Done():
+        return
+      }
+
+      select {
+      case ev, ok := <-events:
+        if !ok {
+          return
+        }
+        if ev.Deleted {
+          delete(values, ev.Key)
+        } else {
+          values[ev.Key] = ev.Value
+        }
+      case <-ctx.Done():
+        return
+      }
+    }
+  }()
+  return updates, nil
+}
+
+func kvConfig(values map[string]string, prefix string, opts KVOptions) Config {
+  sep := opts.Separator
+  if len(sep) < 1 {
+    sep = "/"
+  }
+
+  vars := make([]keyValue, 0, len(values))
+  for key, value := range values {
+    if !hasKeyPrefix(key, prefix, sep) {
+      continue
+    }
+
+    path := make([]string, 0, 4)
+    for _, name := range strings.Split(key[len(prefix):], sep) {
+      if len(name) > 0 {
+        path = append(path, name)
+      }
+    }
+    if len(path) > 0 {
+      vars = append(vars, keyValue{key: key, path: path, value: value})
+    }
+  }
+
+  return make(Config).setPathValues(vars, opts.Coerce, nil)
+}
+
+// hasKeyPrefix returns true if the key is the prefix itself or it's nested
+// into the prefix, so /app doesn't match /application/x
+func hasKeyPrefix(key, prefix, sep string) bool {
+  if !strings.HasPrefix(key, prefix) {
+    return false
+  }
+  rest := key[len(prefix):]
+  return len(prefix) < 1 || len(rest) < 1 ||
+    strings.HasSuffix(prefix, sep) || strings.HasPrefix(rest, sep)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Memory store
+///////////////////////////////////////////////////////////////////////////////
+
+// MemoryKV is the in-memory KVSource for tests
+type MemoryKV struct {
+  mx       sync.Mutex
+  data     map[string]string
+  watchers map[*memoryKVWatcher]struct{}
+}
+
+// memoryKVWatcher queues events, so the store never waits for the receiver
+type memoryKVWatcher struct {
+  mx     sync.Mutex
+  prefix string
+  queue  []KVEvent
+  signal chan struct{}
+  events chan KVEvent
+}
+
+func NewMemoryKV() *MemoryKV {
+  return &MemoryKV{
+    data:     make(map[string]string),
+    watchers: make(map[*memoryKVWatcher]struct{}),
+  }
+}
+
+// Put sets the value of the key, watchers receive the changes in order
+func (kv *MemoryKV) Put(key, value string) {
+  kv.mx.Lock()
+  defer kv.mx.Unlock()
+  kv.data[key] = value
+  kv.notify(KVEvent{Key: key, Value: value})
+}
+
+// Delete the key
+func (kv *MemoryKV) Delete(key string) {
+  kv.mx.Lock()
+  defer kv.mx.Unlock()
+  if _, ok := kv.data[key]; ok {
+    delete(kv.data, key)
+    kv.notify(KVEvent{Key: key, Deleted: true})
+  }
+}
+
+func (kv *MemoryKV) List(ctx context.Context, prefix string) ([]KVPair, error) {
+  kv.mx.Lock()
+  defer kv.mx.Unlock()
+  pairs := make([]KVPair, 0, len(kv.data))
+  for key, value := range kv.data {
+    if strings.HasPrefix(key, prefix) {
+      pairs = append(pairs, KVPair{Key: key, Value: value})
+    }
+  }
+  sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+  return pairs, nil
+}
+
+func (kv *MemoryKV) Get(ctx context.Context, key string) (string, error) {
+  kv.mx.Lock()
+  defer kv.mx.Unlock()
+  value, ok := kv.data[key]
+  if !ok {
+    return "", ErrNoValue
+  }
+  return value, nil
+}
+
+func (kv *MemoryKV) Watch(ctx context.Context, prefix string) (<-chan KVEvent, error) {
+  w := &memoryKVWatcher{
+    prefix: prefix,
+    signal: make(chan struct{}, 1),
+    events: make(chan KVEvent),
+  }
+
+  kv.mx.Lock()
+  kv.watchers[w] = struct{}{}
+  kv.mx.Unlock()
+
+  go func() {
+    defer close(w.events)
+    defer func() {
+      kv.mx.Lock()
+      delete(kv.watchers, w)
+      kv.mx.Unlock()
+    }()
+
+    for {
+      select {
+      case <-w.signal:
+      case <-ctx.Done():
+        return
+      }
+
+      w.mx.Lock()
+      queue := w.queue
+      w.queue = nil
+      w.mx.Unlock()
+
+      for _, ev := range queue {
+        select {
+        case w.events <- ev:
+        case <-ctx.Done():
+          return
+        }
+      }
+    }
+  }()
+  return w.events, nil
+}
+
+// notify queues the event for the watchers, it's called under kv.mx
+func (kv *MemoryKV) notify(ev KVEvent) {
+  for w := range kv.watchers {
+    if !strings.HasPrefix(ev.Key, w.prefix) {
+      continue
+    }
+    w.mx.Lock()
+    w.queue = append(w.queue, ev)
+    w.mx.Unlock()
+
+    select {
+    case w.signal <- struct{}{}:
+    default: // The watcher is already signaled
+    }
+  }
+}
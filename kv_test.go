@@ -0,0 +1,138 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "context"
+  "errors"
+  "reflect"
+  "strconv"
+  "testing"
+  "time"
+)
+
+type failingListKV struct {
+  *MemoryKV
+}
+
+func (failingListKV) List(ctx context.Context, prefix string) ([]KVPair, error) {
+  return nil, errors.New("list failed")
+}
+
+func TestFromKVPrefix(t *testing.T) {
+  kv := NewMemoryKV()
+  kv.Put("/app/db/host", "localhost")
+  kv.Put("/app/servers/0", "a")
+  kv.Put("/app/servers/1", "b")
+  kv.Put("/application/x", "other")
+
+  expect := Config{
+    "db":      Config{"host": "localhost"},
+    "servers": ConfigArr{"a", "b"},
+  }
+  for _, prefix := range []string{"/app", "/app/"} {
+    conf, err := FromKV(context.Background(), kv, prefix, KVOptions{})
+    if nil != err {
+      t.Fatal(err)
+    }
+    if !reflect.DeepEqual(expect, conf) {
+      t.Errorf("%s: expected %#v, got %#v", prefix, expect, conf)
+    }
+  }
+}
+
+func TestMemoryKVSlowWatcher(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+
+  kv := NewMemoryKV()
+  events, err := kv.Watch(ctx, "/app")
+  if nil != err {
+    t.Fatal(err)
+  }
+
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    for i := 0; i < 100; i++ {
+      kv.Put("/app/key", strconv.Itoa(i))
+    }
+    kv.Get(ctx, "/app/key")
+  }()
+  select {
+  case <-done:
+  case <-time.After(5 * time.Second):
+    t.Fatal("store is blocked by the watcher")
+  }
+
+  for i := 0; i < 100; i++ {
+    if ev := <-events; strconv.Itoa(i) != ev.Value {
+      t.Fatalf("expected value %d, got %#v", i, ev)
+    }
+  }
+}
+
+func TestWatchKV(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+
+  kv := NewMemoryKV()
+  kv.Put("/app/a", "1")
+  updates, err := WatchKV(ctx, kv, "/app", KVOptions{Coerce: true})
+  if nil != err {
+    t.Fatal(err)
+  }
+  if conf := <-updates; 1 != conf.IntOrDefault("a", 0) {
+    t.Errorf("expected the current config, got %#v", conf)
+  }
+
+  kv.Put("/app/b", "x")
+  if conf := <-updates; "x" != conf["b"] {
+    t.Errorf("expected the updated config, got %#v", conf)
+  }
+
+  cancel()
+  for range updates {
+  }
+}
+
+func TestWatchKVListError(t *testing.T) {
+  kv := NewMemoryKV()
+  if _, err := WatchKV(context.Background(), failingListKV{kv}, "/app", KVOptions{}); nil == err {
+    t.Fatal("expected list error")
+  }
+
+  // The watch must be stopped
+  for i := 0; i < 100; i++ {
+    kv.mx.Lock()
+    count := len(kv.watchers)
+    kv.mx.Unlock()
+    if 0 == count {
+      return
+    }
+    time.Sleep(10 * time.Millisecond)
+  }
+  t.Error("watch is not stopped after the list error")
+}
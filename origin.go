@@ -0,0 +1,137 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "strconv"
+  "strings"
+)
+
+// Origin describes where the config value came from
+type Origin struct {
+  Source string // Name of the layer
+  File   string // File of the layer if it was loaded from the file
+  Line   int    // Line in the file, 0 if the format doesn't provide it
+  Key    string // Original name, e.g. environment variable or command line flag
+}
+
+// Origins of the leaf values by the dotted paths, arrays are leaf values
+type Origins map[string]Origin
+
+func (o Origin) String() string {
+  s := o.Source
+  if len(o.File) > 0 {
+    file := o.File
+    if o.Line > 0 {
+      file += ":" + strconv.Itoa(o.Line)
+    }
+    s = strings.TrimSpace(s + " " + file)
+  }
+  if len(o.Key) > 0 {
+    s = strings.TrimSpace(s + " " + o.Key)
+  }
+  return s
+}
+
+// Dump writes each leaf value of the config with its origin if it's known
+//
+// Example:
+//   db.host = "localhost"  # file /etc/app/config.ini:3
+//   db.port = 5432  # env APP_DB__PORT
+func (list Origins) Dump(w io.Writer, conf Config) (err error) {
+  walkLeaves(conf, "", func(path string, value interface{}) {
+    if nil != err {
+      return
+    }
+    data, e := json.Marshal(value)
+    if nil != e {
+      data = []byte(fmt.Sprintf("%v", value))
+    }
+    if o, ok := list[path]; ok {
+      _, err = fmt.Fprintf(w, "%s = %s  # %s\n", path, data, o)
+    } else {
+      _, err = fmt.Fprintf(w, "%s = %s\n", path, data)
+    }
+  })
+  return
+}
+
+func (list Origins) lookup(path string) (Origin, bool) {
+  for len(path) > 0 {
+    if o, ok := list[path]; ok {
+      return o, true
+    }
+    i := strings.LastIndexByte(path, '.')
+    if i < 0 {
+      break
+    }
+    path = path[:i]
+  }
+  return Origin{}, false
+}
+
+// leafOrigin returns origin of the leaf, values of the array
+// have the first line of the array items
+func (list Origins) leafOrigin(path string) (Origin, bool) {
+  if o, ok := list[path]; ok {
+    return o, true
+  }
+  var (
+    prefix = path + "."
+    found  = false
+    result Origin
+  )
+  for p, o := range list {
+    if strings.HasPrefix(p, prefix) && (!found || o.Line < result.Line) {
+      result, found = o, true
+    }
+  }
+  result.Key = "" // Key of the single item is misleading
+  return result, found
+}
+
+// mergeOrigins updates the config by the values of c and their origins
+func mergeOrigins(conf Config, origins Origins, c Config, list Origins) {
+  conf.Update(c)
+  walkLeaves(c, "", func(path string, _ interface{}) {
+    if o, ok := list.leafOrigin(path); ok {
+      origins[path] = o
+    }
+  })
+}
+
+// walkLeaves calls the function for each non Config value in the sorted order
+func walkLeaves(conf Config, path string, fn func(path string, value interface{})) {
+  for _, key := range sortedKeys(conf) {
+    if c, ok := conf[key].(Config); ok {
+      walkLeaves(c, joinPath(path, key), fn)
+    } else {
+      fn(joinPath(path, key), conf[key])
+    }
+  }
+}
@@ -0,0 +1,144 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "flag"
+  "strings"
+  "testing"
+  "testing/fstest"
+)
+
+func TestStackOrigins(t *testing.T) {
+  fsys := fstest.MapFS{
+    "app.yaml": {Data: []byte("base: &base\n  host: localhost\napp:\n  <<: *base\n  port: 80\n  list:\n    - a\nflow: {on: 1}\n")},
+    "app.toml": {Data: []byte("name = \"app\"\ninline = { a = 1 }\n\n[db]\nhost = \"db\"\n\n[[items]]\nn = 1\n")},
+  }
+
+  tests := []struct {
+    file string
+    path string
+    line int
+  }{
+    {file: "app.yaml", path: "base.host", line: 2},
+    {file: "app.yaml", path: "app.host", line: 2},
+    {file: "app.yaml", path: "app.port", line: 5},
+    {file: "app.yaml", path: "app.list.0", line: 6},
+    {file: "app.yaml", path: "flow.on", line: 8},
+    {file: "app.toml", path: "name", line: 1},
+    {file: "app.toml", path: "inline.a", line: 2},
+    {file: "app.toml", path: "db.host", line: 5},
+    {file: "app.toml", path: "items.0.n", line: 8},
+  }
+  for _, test := range tests {
+    s := NewStack()
+    if err := s.LoadFS("file", PriorityFile, fsys, test.file, ""); nil != err {
+      t.Fatal(err)
+    }
+    s.Resolve()
+    if o, ok := s.Origin(test.path); !ok || test.file != o.File || test.line != o.Line {
+      t.Errorf("%s %s: expected line %d, got %#v", test.file, test.path, test.line, o)
+    }
+  }
+}
+
+func TestStackDump(t *testing.T) {
+  s1, s2 := NewStack(), NewStack()
+  s1.Set("one", PriorityDefaults, Config{"a": 1})
+  s2.Set("two", PriorityDefaults, Config{"a": 2})
+  conf := s1.Resolve()
+  s2.Resolve()
+
+  // Changes of the returned config don't affect the stack
+  conf.Set("a", 3)
+
+  var buf bytes.Buffer
+  if err := s1.Dump(&buf); nil != err {
+    t.Fatal(err)
+  }
+  if expect := "a = 1  # one\n"; expect != buf.String() {
+    t.Errorf("expected %q, got %q", expect, buf.String())
+  }
+  if o, _ := s2.Origin("a"); "two" != o.Source {
+    t.Errorf("expected origin of the second stack, got %#v", o)
+  }
+  if strings.Contains(buf.String(), "two") {
+    t.Errorf("origins of the stacks are mixed: %q", buf.String())
+  }
+}
+
+func TestStackOriginsEnvFlags(t *testing.T) {
+  t.Setenv("TESTAPP_DB__HOST", "db.local")
+  t.Setenv("TESTAPP_DB__PORT", "6432")
+
+  flags := flag.NewFlagSet("app", flag.ContinueOnError)
+  binding := BindFlags(flags, []Flag{
+    {Path: "db.port", Name: "port", Default: 5432},
+    {Path: "debug", Default: false},
+  })
+  if err := flags.Parse([]string{"-port", "7432"}); nil != err {
+    t.Fatal(err)
+  }
+
+  s := NewStack()
+  s.Set("defaults", PriorityDefaults, Config{"db": Config{"user": "app"}})
+  if err := s.LoadEnv("env", PriorityEnv, EnvOptions{Prefix: "TESTAPP", Separator: "__"}); nil != err {
+    t.Fatal(err)
+  }
+  if err := s.LoadFlags("flags", PriorityFlags, binding); nil != err {
+    t.Fatal(err)
+  }
+  s.Override("db.user", "root")
+  s.Resolve()
+
+  tests := []struct {
+    path   string
+    expect Origin
+  }{
+    {path: "db.host", expect: Origin{Source: "env", Key: "TESTAPP_DB__HOST"}},
+    {path: "db.port", expect: Origin{Source: "flags", Key: "-port"}},
+    {path: "db.user", expect: Origin{Source: RuntimeLayer}},
+  }
+  for _, test := range tests {
+    if o, ok := s.Origin(test.path); !ok || test.expect != o {
+      t.Errorf("%s: expected %#v, got %#v", test.path, test.expect, o)
+    }
+  }
+  if _, ok := s.Origin("debug"); ok {
+    t.Error("debug: not set flag must not have the origin")
+  }
+
+  var buf bytes.Buffer
+  if err := s.Dump(&buf); nil != err {
+    t.Fatal(err)
+  }
+  expect := "db.host = \"db.local\"  # env TESTAPP_DB__HOST\n" +
+    "db.port = 7432  # flags -port\n" +
+    "db.user = \"root\"  # runtime\n"
+  if expect != buf.String() {
+    t.Errorf("expected dump %q, got %q", expect, buf.String())
+  }
+}
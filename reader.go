@@ -0,0 +1,156 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "context"
+  "encoding/json"
+  "io"
+  "io/ioutil"
+)
+
+// ReaderOptions describes limits of the FromReader
+type ReaderOptions struct {
+  // MaxSize of the data in bytes, 0 means no limit
+  MaxSize int64
+}
+
+var (
+  // ReaderDefaults is used by FromReader
+  ReaderDefaults = ReaderOptions{}
+)
+
+// FromReader reads config of the format from the reader (e.g. stdin),
+// empty format means the format is detected by the content
+func FromReader(r io.Reader, format string) (Config, error) {
+  return FromReaderContext(context.Background(), r, format, ReaderDefaults)
+}
+
+// FromReaderContext reads config with the limits, reading stops with the context
+// error when the context is done. The context is checked between reads,
+// so blocked read is not interrupted. JSON is decoded while reading
+// without buffering of the whole data.
+func FromReaderContext(ctx context.Context, r io.Reader, format string, opts ReaderOptions) (Config, error) {
+  r = &limitReader{ctx: ctx, r: r, left: opts.MaxSize, limit: opts.MaxSize > 0}
+
+  if jsonCodec == FormatCodec(format) {
+    return decodeJSONStream(r)
+  }
+
+  data, err := ioutil.ReadAll(r)
+  if nil != err {
+    return nil, err
+  }
+  return FromData(data, format)
+}
+
+type limitReader struct {
+  ctx   context.Context
+  r     io.Reader
+  left  int64
+  limit bool
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+  if err := r.ctx.Err(); nil != err {
+    return 0, err
+  }
+  if r.limit && r.left < 0 {
+    return 0, ErrSizeLimit
+  }
+  if r.limit && int64(len(p)) > r.left+1 {
+    p = p[:r.left+1] // One more byte to detect exceeding of the limit
+  }
+
+  n, err := r.r.Read(p)
+  if r.limit {
+    if r.left -= int64(n); r.left < 0 {
+      return 0, ErrSizeLimit
+    }
+  }
+  return n, err
+}
+
+// decodeJSONStream reads JSON object token by token into the Config,
+// the decoder keeps in memory only not decoded part of the data
+func decodeJSONStream(r io.Reader) (Config, error) {
+  dec := json.NewDecoder(r)
+  value, err := decodeJSONValue(dec)
+  if nil != err {
+    return nil, err
+  }
+  if _, err = dec.Token(); io.EOF != err {
+    if nil == err {
+      return nil, ErrInvalidConfigFormat // Data after the value
+    }
+    return nil, err
+  }
+
+  switch v := value.(type) {
+  case nil:
+    return make(Config), nil
+  case Config:
+    return v, nil
+  }
+  return nil, ErrInvalidConfigFormat
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+  tok, err := dec.Token()
+  if nil != err {
+    if io.EOF == err {
+      return nil, io.ErrUnexpectedEOF
+    }
+    return nil, err
+  }
+
+  switch tok {
+  case json.Delim('{'):
+    conf := make(Config)
+    for dec.More() {
+      key, err := dec.Token()
+      if nil != err {
+        return nil, err
+      }
+      if conf[key.(string)], err = decodeJSONValue(dec); nil != err {
+        return nil, err
+      }
+    }
+    _, err = dec.Token() // }
+    return conf, err
+  case json.Delim('['):
+    arr := make(ConfigArr, 0)
+    for dec.More() {
+      value, err := decodeJSONValue(dec)
+      if nil != err {
+        return nil, err
+      }
+      arr = append(arr, value)
+    }
+    _, err = dec.Token() // ]
+    return arr, err
+  }
+  return tok, nil
+}
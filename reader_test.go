@@ -0,0 +1,130 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "bytes"
+  "context"
+  "errors"
+  "io"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+// cancelReader cancels the context after the first read
+type cancelReader struct {
+  r      io.Reader
+  cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+  defer r.cancel()
+  if len(p) > 4 {
+    p = p[:4]
+  }
+  return r.r.Read(p)
+}
+
+func TestFromReaderJSONStream(t *testing.T) {
+  data := `{"a": 1, "b": {"c": [1, "x", {"d": null}, []], "e": true}, "f": 1.5, "a": 2}`
+
+  expect, err := FromData([]byte(data), "json")
+  if nil != err {
+    t.Fatal(err)
+  }
+  conf, err := FromReader(strings.NewReader(data), "json")
+  if nil != err {
+    t.Fatal(err)
+  }
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected the same as FromData %#v, got %#v", expect, conf)
+  }
+
+  for _, data := range []string{"", "null", " \n"} {
+    conf, err = FromReader(strings.NewReader(data), "json")
+    if "null" == data {
+      if nil != err || 0 != len(conf) {
+        t.Errorf("%q: expected empty config, got %#v (%v)", data, conf, err)
+      }
+    } else if nil == err {
+      t.Errorf("%q: expected error", data)
+    }
+  }
+}
+
+func TestFromReaderJSONErrors(t *testing.T) {
+  tests := []string{
+    `{"a": 1} {"b": 2}`,
+    `{"a": 1} x`,
+    `{"a": 1`,
+    `[1, 2]`,
+    `"text"`,
+  }
+  for _, data := range tests {
+    if conf, err := FromReader(strings.NewReader(data), "json"); nil == err {
+      t.Errorf("%q: expected error, got %#v", data, conf)
+    }
+  }
+}
+
+func TestFromReaderMaxSize(t *testing.T) {
+  ctx := context.Background()
+  tests := []struct {
+    data   string
+    format string
+  }{
+    {data: `{"key": "value"}`, format: "json"},
+    {data: "key: value\n", format: "yaml"},
+    {data: "key: value\n", format: ""},
+  }
+  for _, test := range tests {
+    size := int64(len(test.data))
+    if _, err := FromReaderContext(ctx, strings.NewReader(test.data), test.format, ReaderOptions{MaxSize: size - 1}); !errors.Is(err, ErrSizeLimit) {
+      t.Errorf("%s: expected ErrSizeLimit, got %v", test.format, err)
+    }
+    conf, err := FromReaderContext(ctx, strings.NewReader(test.data), test.format, ReaderOptions{MaxSize: size})
+    if nil != err || "value" != conf.String("key") {
+      t.Errorf("%s: expected the config within the limit, got %#v (%v)", test.format, conf, err)
+    }
+  }
+}
+
+func TestFromReaderContext(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+  if _, err := FromReaderContext(ctx, strings.NewReader("a: 1"), "yaml", ReaderOptions{}); !errors.Is(err, context.Canceled) {
+    t.Errorf("canceled: expected context.Canceled, got %v", err)
+  }
+
+  for _, format := range []string{"json", "yaml"} {
+    ctx, cancel = context.WithCancel(context.Background())
+    data := bytes.Repeat([]byte(" "), 64)
+    r := &cancelReader{r: io.MultiReader(bytes.NewReader(data), strings.NewReader(`{"a": 1}`)), cancel: cancel}
+    if _, err := FromReaderContext(ctx, r, format, ReaderOptions{}); !errors.Is(err, context.Canceled) {
+      t.Errorf("%s: expected context.Canceled while reading, got %v", format, err)
+    }
+  }
+}
@@ -0,0 +1,296 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "fmt"
+  "io"
+  "io/fs"
+  "os"
+  "sort"
+  "sync"
+)
+
+// Priorities of the common layers, layer with the higher priority
+// overrides values of the lower ones
+const (
+  PriorityDefaults = 0
+  PriorityFile     = 100
+  PriorityConfD    = 200
+  PriorityEnv      = 300
+  PriorityFlags    = 400
+  PriorityRuntime  = 500
+)
+
+// RuntimeLayer is the name of the layer changed by Stack.Override
+const RuntimeLayer = "runtime"
+
+// LoadFunc loads the layer values, it's called again by Stack.Reload
+type LoadFunc func() (Config, error)
+
+type loadOriginsFunc func() (Config, Origins, error)
+
+type layer struct {
+  name     string
+  priority int
+  index    int // Order of adding for the layers with equal priority
+  conf     Config
+  origins  Origins
+  load     loadOriginsFunc
+}
+
+// Stack of named config layers merged in the order of priorities
+type Stack struct {
+  mx       sync.RWMutex
+  layers   []*layer
+  count    int
+  resolved Config
+  origins  Origins
+}
+
+func NewStack() *Stack {
+  return &Stack{}
+}
+
+// Set adds or replaces the layer by the name
+func (s *Stack) Set(name string, priority int, conf Config) *Stack {
+  s.mx.Lock()
+  defer s.mx.Unlock()
+  s.set(&layer{name: name, priority: priority, conf: conf})
+  return s
+}
+
+// Load adds or replaces the layer loaded by the function,
+// the layer may be reloaded later by Reload
+func (s *Stack) Load(name string, priority int, load LoadFunc) error {
+  return s.load(name, priority, func() (Config, Origins, error) {
+    conf, err := load()
+    return conf, nil, err
+  })
+}
+
+// LoadFile adds or replaces the layer loaded from the file, origins of the values
+// have the file name and lines if the format provides them
+func (s *Stack) LoadFile(name string, priority int, filename, ftype string) error {
+  return s.load(name, priority, func() (Config, Origins, error) {
+    return newFileLoader().load(filename, ftype)
+  })
+}
+
+// LoadFS adds or replaces the layer loaded from the file of the file system
+func (s *Stack) LoadFS(name string, priority int, fsys fs.FS, filename, ftype string) error {
+  return s.load(name, priority, func() (Config, Origins, error) {
+    return newFSLoader(fsys).load(filename, ftype)
+  })
+}
+
+// LoadEnv adds or replaces the layer of the environment variables,
+// origins of the values have names of the variables
+func (s *Stack) LoadEnv(name string, priority int, opts EnvOptions) error {
+  return s.load(name, priority, func() (Config, Origins, error) {
+    origins := make(Origins)
+    conf := make(Config).updateEnviron(os.Environ(), opts, origins)
+    return conf, origins, nil
+  })
+}
+
+// LoadFlags adds or replaces the layer of the flags explicitly set
+// in the command line, origins of the values have names of the flags
+func (s *Stack) LoadFlags(name string, priority int, flags *FlagBinding) error {
+  return s.load(name, priority, func() (Config, Origins, error) {
+    origins := make(Origins)
+    conf := flags.apply(make(Config), origins)
+    return conf, origins, nil
+  })
+}
+
+func (s *Stack) load(name string, priority int, load loadOriginsFunc) error {
+  conf, origins, err := load()
+  if nil != err {
+    return fmt.Errorf("%s: %w", name, err)
+  }
+
+  s.mx.Lock()
+  defer s.mx.Unlock()
+  s.set(&layer{name: name, priority: priority, conf: conf, origins: origins, load: load})
+  return nil
+}
+
+// Reload layers with the names or all loadable layers if no names
+func (s *Stack) Reload(names ...string) error {
+  s.mx.RLock()
+  layers := make([]*layer, 0, len(s.layers))
+  for _, l := range s.layers {
+    if nil != l.load && (len(names) < 1 || hasString(names, l.name)) {
+      layers = append(layers, l)
+    }
+  }
+  s.mx.RUnlock()
+
+  for _, l := range layers {
+    conf, origins, err := l.load()
+    if nil != err {
+      return fmt.Errorf("%s: %w", l.name, err)
+    }
+
+    s.mx.Lock()
+    l.conf, l.origins = conf, origins
+    s.mx.Unlock()
+  }
+  return nil
+}
+
+// Remove the layer by the name
+func (s *Stack) Remove(name string) *Stack {
+  s.mx.Lock()
+  defer s.mx.Unlock()
+  for i, l := range s.layers {
+    if l.name == name {
+      s.layers = append(s.layers[:i], s.layers[i+1:]...)
+      break
+    }
+  }
+  return s
+}
+
+// Layer returns config of the layer or nil
+func (s *Stack) Layer(name string) Config {
+  s.mx.RLock()
+  defer s.mx.RUnlock()
+  if l := s.find(name); nil != l {
+    return l.conf
+  }
+  return nil
+}
+
+// Names returns the layer names from the lowest priority to the highest
+func (s *Stack) Names() []string {
+  s.mx.RLock()
+  defer s.mx.RUnlock()
+  names := make([]string, 0, len(s.layers))
+  for _, l := range s.layers {
+    names = append(names, l.name)
+  }
+  return names
+}
+
+// Override sets the value in the runtime layer which has the highest priority
+func (s *Stack) Override(path string, value interface{}) *Stack {
+  s.mx.Lock()
+  defer s.mx.Unlock()
+  l := s.find(RuntimeLayer)
+  if nil == l {
+    l = &layer{name: RuntimeLayer, priority: PriorityRuntime, conf: make(Config)}
+    s.set(l)
+  } else if nil == l.conf {
+    l.conf = make(Config)
+  }
+  l.conf.Set(path, value)
+  return s
+}
+
+// Resolve merges all layers into the new config, layers are not changed.
+// Origins of the values are available by Stack.Origin and Stack.Dump
+// until the next Resolve.
+func (s *Stack) Resolve() Config {
+  s.mx.Lock()
+  defer s.mx.Unlock()
+
+  var (
+    conf   = make(Config)
+    merged = make(Origins)
+  )
+  for _, l := range s.layers {
+    c := l.conf.Copy()
+    conf.Update(c)
+    walkLeaves(c, "", func(path string, _ interface{}) {
+      o, _ := l.origins.leafOrigin(path)
+      o.Source = l.name
+      merged[path] = o
+    })
+  }
+
+  // Values of the lower layers may be replaced by the upper ones
+  origins := make(Origins, len(merged))
+  walkLeaves(conf, "", func(path string, _ interface{}) {
+    if o, ok := merged[path]; ok {
+      origins[path] = o
+    }
+  })
+
+  s.resolved, s.origins = conf.Copy(), origins
+  return conf
+}
+
+// Origin returns the source of the value in the last resolved config,
+// the path inside of the array returns the origin of the whole array
+func (s *Stack) Origin(path string) (Origin, bool) {
+  s.mx.RLock()
+  defer s.mx.RUnlock()
+  return s.origins.lookup(path)
+}
+
+// Dump writes each value of the last resolved config with its origin,
+// see Origins.Dump
+func (s *Stack) Dump(w io.Writer) error {
+  s.mx.RLock()
+  defer s.mx.RUnlock()
+  return s.origins.Dump(w, s.resolved)
+}
+
+func (s *Stack) set(l *layer) {
+  if old := s.find(l.name); nil != old {
+    old.priority, old.conf, old.origins, old.load = l.priority, l.conf, l.origins, l.load
+  } else {
+    s.count++
+    l.index = s.count
+    s.layers = append(s.layers, l)
+  }
+
+  sort.SliceStable(s.layers, func(i, j int) bool {
+    if s.layers[i].priority == s.layers[j].priority {
+      return s.layers[i].index < s.layers[j].index
+    }
+    return s.layers[i].priority < s.layers[j].priority
+  })
+}
+
+func (s *Stack) find(name string) *layer {
+  for _, l := range s.layers {
+    if l.name == name {
+      return l
+    }
+  }
+  return nil
+}
+
+func hasString(list []string, s string) bool {
+  for _, it := range list {
+    if it == s {
+      return true
+    }
+  }
+  return false
+}
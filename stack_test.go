@@ -0,0 +1,127 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "reflect"
+  "testing"
+)
+
+func TestStackPriority(t *testing.T) {
+  s := NewStack()
+  s.Set("env", PriorityEnv, Config{"db": Config{"host": "env"}})
+  s.Set("defaults", PriorityDefaults, Config{"db": Config{"host": "default", "port": 5432}, "debug": false})
+  s.Set("file", PriorityFile, Config{"db": Config{"host": "file"}, "debug": true})
+  s.Set("file2", PriorityFile, Config{"debug": "file2"})
+
+  if expect := []string{"defaults", "file", "file2", "env"}; !reflect.DeepEqual(expect, s.Names()) {
+    t.Errorf("expected layers %v, got %v", expect, s.Names())
+  }
+
+  expect := Config{"db": Config{"host": "env", "port": 5432}, "debug": "file2"}
+  conf := s.Resolve()
+  if !reflect.DeepEqual(expect, conf) {
+    t.Errorf("expected %#v, got %#v", expect, conf)
+  }
+
+  // Layers are not changed by the resolve
+  conf.Set("db.port", 1)
+  if 5432 != s.Layer("defaults").IntOrDefault("db.port", 0) {
+    t.Errorf("layer is changed by the resolved config: %#v", s.Layer("defaults"))
+  }
+
+  // Replacing the layer keeps its position
+  s.Set("file", PriorityFile, Config{"debug": "file"})
+  if expect := []string{"defaults", "file", "file2", "env"}; !reflect.DeepEqual(expect, s.Names()) {
+    t.Errorf("expected layers %v, got %v", expect, s.Names())
+  }
+}
+
+func TestStackOverrideRemove(t *testing.T) {
+  s := NewStack()
+  s.Set("flags", PriorityFlags, Config{"debug": false, "port": 80})
+  s.Override("debug", true)
+
+  if conf := s.Resolve(); true != conf["debug"] || 80 != conf["port"] {
+    t.Errorf("override: expected debug=true port=80, got %#v", conf)
+  }
+  if expect := []string{"flags", RuntimeLayer}; !reflect.DeepEqual(expect, s.Names()) {
+    t.Errorf("expected layers %v, got %v", expect, s.Names())
+  }
+
+  s.Remove(RuntimeLayer).Remove("unknown")
+  if conf := s.Resolve(); false != conf["debug"] {
+    t.Errorf("remove: expected debug=false, got %#v", conf)
+  }
+  if nil != s.Layer(RuntimeLayer) {
+    t.Error("remove: runtime layer still exists")
+  }
+}
+
+func TestStackReload(t *testing.T) {
+  var (
+    s       = NewStack()
+    version = 1
+    fail    = false
+  )
+  err := s.Load("remote", PriorityFile, func() (Config, error) {
+    if fail {
+      return nil, errors.New("unavailable")
+    }
+    return Config{"version": version}, nil
+  })
+  if nil != err {
+    t.Fatal(err)
+  }
+  s.Set("static", PriorityDefaults, Config{"version": 0, "name": "app"})
+
+  version = 2
+  if err = s.Reload(); nil != err {
+    t.Fatal(err)
+  }
+  if conf := s.Resolve(); 2 != conf["version"] || "app" != conf["name"] {
+    t.Errorf("reload: expected version 2, got %#v", conf)
+  }
+
+  // The failed reload keeps the previous values
+  fail = true
+  if err = s.Reload("remote"); nil == err {
+    t.Error("reload: expected error")
+  }
+  if 2 != s.Layer("remote")["version"] {
+    t.Errorf("failed reload: expected version 2, got %#v", s.Layer("remote"))
+  }
+  if err = s.Reload("static"); nil != err {
+    t.Errorf("static layer is not reloadable, expected no error, got %v", err)
+  }
+
+  if err = s.Load("broken", PriorityFile, func() (Config, error) { return nil, errors.New("broken") }); nil == err {
+    t.Error("load: expected error")
+  }
+  if nil != s.Layer("broken") {
+    t.Error("load: failed layer must not be added")
+  }
+}
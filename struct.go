@@ -0,0 +1,109 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "reflect"
+  "strings"
+)
+
+// Tags of the struct fields, e.g. `config:"port,omitempty" default:"8080" validate:"min=1"`
+const (
+  TagName         = "config"
+  DefaultTagName  = "default"
+  ValidateTagName = "validate"
+
+  // FieldTagName is the tag of the earlier versions, it's used
+  // if the field has no `config` tag
+  FieldTagName = "field"
+)
+
+// structField describes the exported field of the struct
+type structField struct {
+  index     int
+  name      string
+  tagged    bool // The name is set by the tag
+  inline    bool // Fields of the struct are the fields of the parent
+  omitEmpty bool
+  def       string // Default value
+  hasDef    bool
+  field     reflect.StructField
+}
+
+// structFields returns the exported fields with the tag options.
+// Embedded structs without the name are inline.
+func structFields(t reflect.Type) []structField {
+  fields := make([]structField, 0, t.NumField())
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    if len(f.PkgPath) > 0 && !f.Anonymous {
+      continue // Unexported
+    }
+
+    tag, ok := f.Tag.Lookup(TagName)
+    if !ok {
+      tag = f.Tag.Get(FieldTagName)
+    }
+    if "-" == tag {
+      continue
+    }
+
+    parts := strings.Split(tag, ",")
+    sf := structField{index: i, name: parts[0], tagged: len(parts[0]) > 0, field: f}
+    sf.def, sf.hasDef = f.Tag.Lookup(DefaultTagName)
+    for _, opt := range parts[1:] {
+      switch opt {
+      case "omitempty":
+        sf.omitEmpty = true
+        break
+      case "inline", "squash":
+        sf.inline = true
+        break
+      }
+    }
+
+    if f.Anonymous && !sf.tagged && reflect.Struct == indirectType(f.Type).Kind() {
+      sf.inline = true
+    }
+    if sf.inline && reflect.Struct != indirectType(f.Type).Kind() {
+      sf.inline = false
+    }
+    if len(f.PkgPath) > 0 && !sf.inline {
+      continue // Unexported embedded type which is not a struct
+    }
+    if !sf.tagged {
+      sf.name = f.Name
+    }
+    fields = append(fields, sf)
+  }
+  return fields
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+  for reflect.Ptr == t.Kind() {
+    t = t.Elem()
+  }
+  return t
+}
@@ -0,0 +1,289 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "context"
+  "crypto/tls"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "mime"
+  "net/http"
+  "net/url"
+  "os"
+  "path"
+  "strings"
+  "sync"
+)
+
+// URLOptions describes the request of the remote config
+type URLOptions struct {
+  // Format of the config, by default it's detected by the Content-Type,
+  // the URL path extension or the content
+  Format string
+
+  // Header is added to the each request
+  Header http.Header
+
+  // TLSConfig of the default client
+  TLSConfig *tls.Config
+
+  // Client sends requests, TLSConfig is ignored if it's set
+  Client *http.Client
+
+  // CacheFile keeps the copy of the last loaded config which is used
+  // when the endpoint is down or returns invalid config
+  CacheFile string
+
+  // MaxSize of the response body in bytes, 0 means no limit
+  MaxSize int64
+}
+
+// URLSource loads the remote config, requests are conditional
+// by ETag and Last-Modified of the previous response, so polling
+// of not modified config is cheap
+type URLSource struct {
+  mx        sync.Mutex
+  url       string
+  opts      URLOptions
+  client    *http.Client
+  meta      urlCacheMeta
+  conf      Config
+  fromCache bool
+}
+
+type urlCacheMeta struct {
+  ETag         string `json:"etag,omitempty"`
+  LastModified string `json:"last_modified,omitempty"`
+  Format       string `json:"format,omitempty"`
+}
+
+var contentTypes = map[string]string{
+  "text/x-java-properties": "properties",
+  "text/x-properties":      "properties",
+  "text/plain":             "",
+}
+
+// FromURL loads the remote config, see URLSource.Fetch
+func FromURL(ctx context.Context, rawurl string, opts URLOptions) (Config, error) {
+  conf, _, err := NewURLSource(rawurl, opts).Fetch(ctx)
+  return conf, err
+}
+
+// NewURLSource of the config
+func NewURLSource(rawurl string, opts URLOptions) *URLSource {
+  client := opts.Client
+  if nil == client {
+    client = http.DefaultClient
+    if nil != opts.TLSConfig {
+      client = &http.Client{Transport: &http.Transport{
+        Proxy:           http.ProxyFromEnvironment,
+        TLSClientConfig: opts.TLSConfig,
+      }}
+    }
+  }
+  return &URLSource{url: rawurl, opts: opts, client: client}
+}
+
+// Fetch requests the config, changed is false if the server responded
+// that the config is not modified since the previous Fetch. If the endpoint
+// is down and the cache file exists the cached config is returned
+// without error, FromCache reports it.
+func (s *URLSource) Fetch(ctx context.Context) (conf Config, changed bool, err error) {
+  s.mx.Lock()
+  defer s.mx.Unlock()
+
+  first := nil == s.conf
+  if first && len(s.opts.CacheFile) > 0 {
+    s.conf, s.meta, _ = readURLCache(s.opts.CacheFile) // Revalidate the cached copy
+  }
+
+  if changed, err = s.fetch(ctx); nil != err {
+    if nil == s.conf || nil != ctx.Err() || !canUseCache(err) {
+      return nil, false, err
+    }
+    s.fromCache = true
+  }
+  return s.conf.Copy(), changed || first, nil
+}
+
+// FromCache returns true if the last Fetch returned the cached config
+// because the endpoint was down
+func (s *URLSource) FromCache() bool {
+  s.mx.Lock()
+  defer s.mx.Unlock()
+  return s.fromCache
+}
+
+func (s *URLSource) fetch(ctx context.Context) (bool, error) {
+  req, err := http.NewRequest(http.MethodGet, s.url, nil)
+  if nil != err {
+    return false, err
+  }
+  req = req.WithContext(ctx)
+  for name, values := range s.opts.Header {
+    for _, v := range values {
+      req.Header.Add(name, v)
+    }
+  }
+  if nil != s.conf {
+    if len(s.meta.ETag) > 0 {
+      req.Header.Set("If-None-Match", s.meta.ETag)
+    }
+    if len(s.meta.LastModified) > 0 {
+      req.Header.Set("If-Modified-Since", s.meta.LastModified)
+    }
+  }
+
+  resp, err := s.client.Do(req)
+  if nil != err {
+    return false, err
+  }
+  defer resp.Body.Close()
+
+  switch {
+  case http.StatusNotModified == resp.StatusCode && nil != s.conf:
+    s.fromCache = false
+    return false, nil
+  case resp.StatusCode < 200 || resp.StatusCode > 299:
+    return false, &urlStatusError{url: s.url, code: resp.StatusCode, status: resp.Status}
+  }
+
+  format := s.opts.Format
+  if "" == format {
+    format = FormatByContentType(resp.Header.Get("Content-Type"))
+  }
+  if "" == format {
+    if u, err := url.Parse(s.url); nil == err {
+      format = FormatByExtension(path.Base(u.Path))
+    }
+  }
+
+  data, err := ioutil.ReadAll(&limitReader{ctx: ctx, r: resp.Body, left: s.opts.MaxSize, limit: s.opts.MaxSize > 0})
+  if nil != err {
+    return false, err
+  }
+  if "" == format {
+    format = DetectFormat(data)
+  }
+  conf, err := FromData(data, format)
+  if nil != err {
+    return false, fmt.Errorf("%s: %w", s.url, err)
+  }
+
+  s.conf, s.fromCache = conf, false
+  s.meta = urlCacheMeta{
+    ETag:         resp.Header.Get("ETag"),
+    LastModified: resp.Header.Get("Last-Modified"),
+    Format:       format,
+  }
+  if len(s.opts.CacheFile) > 0 {
+    // The cache is the best effort fallback, so the loaded config is valid anyway
+    _ = writeURLCache(s.opts.CacheFile, data, s.meta)
+  }
+  return true, nil
+}
+
+// FormatByContentType returns format name by the MIME type
+// (e.g. application/json, application/x-yaml, application/vnd.api+json)
+// or empty string if the type is unknown
+func FormatByContentType(contentType string) string {
+  mediaType, _, err := mime.ParseMediaType(contentType)
+  if nil != err {
+    return ""
+  }
+  if format, ok := contentTypes[mediaType]; ok {
+    return format
+  }
+
+  subtype := mediaType[strings.IndexByte(mediaType, '/')+1:]
+  if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+    subtype = subtype[i+1:]
+  }
+  subtype = strings.TrimPrefix(subtype, "x-")
+  if nil != FormatCodec(subtype) {
+    return subtype
+  }
+  return ""
+}
+
+// canUseCache returns true for the network, server and decoding errors,
+// client errors like 404 mean the invalid request
+func canUseCache(err error) bool {
+  statusErr, ok := err.(*urlStatusError)
+  return !ok || statusErr.code >= 500
+}
+
+type urlStatusError struct {
+  url    string
+  code   int
+  status string
+}
+
+func (e *urlStatusError) Error() string {
+  return fmt.Sprintf("%s: %s", e.url, e.status)
+}
+
+func readURLCache(filename string) (Config, urlCacheMeta, error) {
+  var meta urlCacheMeta
+  data, err := ioutil.ReadFile(filename + ".meta")
+  if nil != err {
+    return nil, meta, err
+  }
+  if err = json.Unmarshal(data, &meta); nil != err {
+    return nil, meta, err
+  }
+  if data, err = ioutil.ReadFile(filename); nil != err {
+    return nil, meta, err
+  }
+  conf, err := FromData(data, meta.Format)
+  return conf, meta, err
+}
+
+// writeURLCache replaces the cache files by rename,
+// so the reader never gets partially written file
+func writeURLCache(filename string, data []byte, meta urlCacheMeta) error {
+  metaData, err := json.Marshal(meta)
+  if nil != err {
+    return err
+  }
+  if err = writeFileAtomic(filename, data); nil != err {
+    return err
+  }
+  return writeFileAtomic(filename+".meta", metaData)
+}
+
+func writeFileAtomic(filename string, data []byte) error {
+  tmp := filename + ".tmp"
+  if err := ioutil.WriteFile(tmp, data, 0644); nil != err {
+    return err
+  }
+  if err := os.Rename(tmp, filename); nil != err {
+    os.Remove(tmp)
+    return err
+  }
+  return nil
+}
@@ -0,0 +1,122 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "context"
+  "net/http"
+  "net/http/httptest"
+  "path/filepath"
+  "sync/atomic"
+  "testing"
+)
+
+func TestURLSource(t *testing.T) {
+  var (
+    requests int32
+    status   int32 = http.StatusOK
+  )
+  srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    atomic.AddInt32(&requests, 1)
+    if code := int(atomic.LoadInt32(&status)); http.StatusOK != code {
+      w.WriteHeader(code)
+      return
+    }
+    if "v1" == r.Header.Get("If-None-Match") {
+      w.WriteHeader(http.StatusNotModified)
+      return
+    }
+    w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+    w.Header().Set("ETag", "v1")
+    w.Write([]byte("db:\n  host: localhost\n"))
+  }))
+  defer srv.Close()
+
+  var (
+    ctx   = context.Background()
+    cache = filepath.Join(t.TempDir(), "config.cache")
+    src   = NewURLSource(srv.URL+"/config", URLOptions{CacheFile: cache})
+  )
+
+  conf, changed, err := src.Fetch(ctx)
+  if nil != err {
+    t.Fatal(err)
+  }
+  if !changed || "localhost" != conf.String("db.host") {
+    t.Errorf("first fetch: expected changed config, got %v %#v", changed, conf)
+  }
+
+  conf, changed, err = src.Fetch(ctx)
+  if nil != err || changed || "localhost" != conf.String("db.host") {
+    t.Errorf("not modified: expected the same config, got %v %#v (%v)", changed, conf, err)
+  }
+
+  atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+  conf, _, err = NewURLSource(srv.URL+"/config", URLOptions{CacheFile: cache}).Fetch(ctx)
+  if nil != err || "localhost" != conf.String("db.host") {
+    t.Errorf("server error: expected cached config, got %#v (%v)", conf, err)
+  }
+
+  atomic.StoreInt32(&status, http.StatusNotFound)
+  if _, _, err = NewURLSource(srv.URL+"/config", URLOptions{CacheFile: cache}).Fetch(ctx); nil == err {
+    t.Error("not found: expected error")
+  }
+
+  if 4 != atomic.LoadInt32(&requests) {
+    t.Errorf("expected 4 requests, got %d", requests)
+  }
+}
+
+func TestURLSourceMaxSize(t *testing.T) {
+  srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    w.Write([]byte(`{"key": "value"}`))
+  }))
+  defer srv.Close()
+
+  if _, err := FromURL(context.Background(), srv.URL, URLOptions{MaxSize: 4}); nil == err {
+    t.Error("expected size limit error")
+  }
+  conf, err := FromURL(context.Background(), srv.URL, URLOptions{MaxSize: 1024})
+  if nil != err || "value" != conf.String("key") {
+    t.Errorf("expected detected JSON config, got %#v (%v)", conf, err)
+  }
+}
+
+func TestFormatByContentType(t *testing.T) {
+  tests := map[string]string{
+    "application/json":                "json",
+    "application/vnd.api+json":        "json",
+    "application/x-yaml":              "yaml",
+    "application/toml; charset=utf-8": "toml",
+    "text/x-java-properties":          "properties",
+    "text/plain":                      "",
+    "invalid":                         "",
+  }
+  for contentType, expect := range tests {
+    if format := FormatByContentType(contentType); expect != format {
+      t.Errorf("%s: expected %q, got %q", contentType, expect, format)
+    }
+  }
+}
@@ -0,0 +1,429 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "fmt"
+  "math"
+  "net/url"
+  "reflect"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "sync"
+)
+
+// ValidatorFunc checks the non empty value, param is the rule parameter
+// (e.g. "5" of min=5), the error message is used in the ValidationError
+type ValidatorFunc func(value interface{}, param string) error
+
+// Schema of the config validation, the rules by the paths.
+// Paths may contain * for each item of the object or array.
+//
+// Example:
+//   config.Schema{
+//     "db.port":        "required,min=1,max=65535",
+//     "db.password":    "minlen=8",
+//     "log.level":      "oneof=debug info error",
+//     "servers.*.host": "required,hostname",
+//   }
+type Schema map[string]string
+
+type rule struct {
+  name  string
+  param string
+}
+
+var (
+  validators  = map[string]ValidatorFunc{}
+  hostnameEx  = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`)
+  validateRxs sync.Map // Compiled regex rules
+)
+
+func init() {
+  RegisterValidator("min", validateMin)
+  RegisterValidator("max", validateMax)
+  RegisterValidator("minlen", validateMinLen)
+  RegisterValidator("maxlen", validateMaxLen)
+  RegisterValidator("oneof", validateOneOf)
+  RegisterValidator("regex", validateRegex)
+  RegisterValidator("url", validateURL)
+  RegisterValidator("hostname", validateHostname)
+}
+
+// RegisterValidator adds or replaces the rule by the name,
+// it's used in the validate tags and schemas
+func RegisterValidator(name string, fn ValidatorFunc) {
+  validators[name] = fn
+}
+
+// Validate checks the struct fields by the `validate` tags, e.g.
+// `validate:"required,min=1"`. Paths of the errors are the config names
+// of the fields. Rules except required are checked for non empty values only,
+// the regex rule must be the last one as the pattern may contain commas.
+// Invalid values are returned as ValidationErrors.
+func Validate(v interface{}) error {
+  rv := reflect.ValueOf(v)
+  for reflect.Ptr == rv.Kind() && !rv.IsNil() {
+    rv = rv.Elem()
+  }
+  if reflect.Struct != rv.Kind() {
+    return ErrUnsupportedValue
+  }
+
+  var errs ValidationErrors
+  validateStruct(&errs, "", rv)
+  if len(errs) > 0 {
+    return errs
+  }
+  return nil
+}
+
+// Validate checks the config values by the schema rules,
+// see Validate for the rules
+func (conf Config) Validate(schema Schema) error {
+  paths := make([]string, 0, len(schema))
+  for path := range schema {
+    paths = append(paths, path)
+  }
+  sort.Strings(paths)
+
+  var errs ValidationErrors
+  for _, path := range paths {
+    rules := parseRules(schema[path])
+    for _, it := range expandPath(conf, "", strings.Split(path, ".")) {
+      checkRules(&errs, it.path, it.value, it.found && !isEmptyItem(it.value), rules)
+    }
+  }
+  if len(errs) > 0 {
+    return errs
+  }
+  return nil
+}
+
+func validateStruct(errs *ValidationErrors, path string, v reflect.Value) {
+  for _, f := range structFields(v.Type()) {
+    fv := v.Field(f.index)
+    if f.inline {
+      if fv = reflect.Indirect(fv); fv.IsValid() {
+        validateStruct(errs, path, fv)
+      }
+      continue
+    }
+    if !fv.CanInterface() {
+      continue
+    }
+
+    fpath := joinPath(path, f.name)
+    if tag := f.field.Tag.Get(ValidateTagName); len(tag) > 0 {
+      value := fv
+      for reflect.Ptr == value.Kind() && !value.IsNil() {
+        value = value.Elem()
+      }
+      checkRules(errs, fpath, value.Interface(), !isEmptyValue(value), parseRules(tag))
+    }
+    validateNested(errs, fpath, fv)
+  }
+}
+
+func validateNested(errs *ValidationErrors, path string, v reflect.Value) {
+  switch v.Kind() {
+  case reflect.Ptr, reflect.Interface:
+    if !v.IsNil() {
+      validateNested(errs, path, v.Elem())
+    }
+    break
+  case reflect.Struct:
+    if !hasUnmarshaler(v.Type()) {
+      validateStruct(errs, path, v)
+    }
+    break
+  case reflect.Slice, reflect.Array:
+    for i := 0; i < v.Len(); i++ {
+      validateNested(errs, joinPath(path, strconv.Itoa(i)), v.Index(i))
+    }
+    break
+  case reflect.Map:
+    keys := make(map[string]reflect.Value, v.Len())
+    for _, k := range v.MapKeys() {
+      if key, err := keyToString(k.Interface()); nil == err {
+        keys[key] = k
+      }
+    }
+    names := make([]string, 0, len(keys))
+    for key := range keys {
+      names = append(names, key)
+    }
+    sort.Strings(names)
+    for _, key := range names {
+      validateNested(errs, joinPath(path, key), v.MapIndex(keys[key]))
+    }
+    break
+  }
+}
+
+func checkRules(errs *ValidationErrors, path string, value interface{}, present bool, rules []rule) {
+  for _, r := range rules {
+    ruleName := r.name
+    if len(r.param) > 0 {
+      ruleName += "=" + r.param
+    }
+
+    if "required" == r.name {
+      if !present {
+        *errs = append(*errs, &ValidationError{Path: path, Value: value, Rule: ruleName, Msg: "value is required"})
+      }
+      continue
+    }
+    if !present {
+      continue
+    }
+
+    fn, ok := validators[r.name]
+    if !ok {
+      *errs = append(*errs, &ValidationError{Path: path, Value: value, Rule: ruleName, Msg: "unknown rule"})
+      continue
+    }
+    if err := fn(value, r.param); nil != err {
+      *errs = append(*errs, &ValidationError{Path: path, Value: value, Rule: ruleName, Msg: err.Error()})
+    }
+  }
+}
+
+// parseRules splits "required,min=1,regex=^[a-z,]+$" into the rules,
+// the regex pattern takes the rest of the string
+func parseRules(s string) []rule {
+  rules := make([]rule, 0, 4)
+  for len(s) > 0 {
+    var item string
+    if strings.HasPrefix(strings.TrimSpace(s), "regex=") {
+      item, s = strings.TrimSpace(s), ""
+    } else if i := strings.IndexByte(s, ','); i >= 0 {
+      item, s = strings.TrimSpace(s[:i]), s[i+1:]
+    } else {
+      item, s = strings.TrimSpace(s), ""
+    }
+    if len(item) < 1 {
+      continue
+    }
+
+    r := rule{name: item}
+    if i := strings.IndexByte(item, '='); i >= 0 {
+      r.name, r.param = item[:i], item[i+1:]
+    }
+    rules = append(rules, r)
+  }
+  return rules
+}
+
+type pathValue struct {
+  path  string
+  value interface{}
+  found bool
+}
+
+// expandPath returns values of the path, * is replaced by each key of the object
+// or each index of the array. Missing value is returned as not found.
+func expandPath(it interface{}, path string, keys []string) []pathValue {
+  if len(keys) < 1 {
+    return []pathValue{{path: path, value: it, found: true}}
+  }
+
+  key := keys[0]
+  switch v := it.(type) {
+  case Config:
+    if "*" == key {
+      var result []pathValue
+      for _, k := range sortedKeys(v) {
+        result = append(result, expandPath(v[k], joinPath(path, k), keys[1:])...)
+      }
+      return result
+    }
+    if next, ok := v[key]; ok {
+      return expandPath(next, joinPath(path, key), keys[1:])
+    }
+    break
+  case ConfigArr:
+    if "*" == key {
+      var result []pathValue
+      for i, next := range v {
+        result = append(result, expandPath(next, joinPath(path, strconv.Itoa(i)), keys[1:])...)
+      }
+      return result
+    }
+    if i, err := strconv.Atoi(key); nil == err && i >= 0 && i < len(v) {
+      return expandPath(v[i], joinPath(path, key), keys[1:])
+    }
+    break
+  }
+  return []pathValue{{path: joinPath(path, strings.Join(keys, "."))}}
+}
+
+func isEmptyItem(value interface{}) bool {
+  switch v := value.(type) {
+  case nil:
+    return true
+  case string:
+    return len(v) < 1
+  case Config:
+    return len(v) < 1
+  case ConfigArr:
+    return len(v) < 1
+  }
+  return false
+}
+
+///////////////////////////////////////////////////////////////////////////////
+/// Validators
+///////////////////////////////////////////////////////////////////////////////
+
+func validateMin(value interface{}, param string) error {
+  return validateLimit(value, param, false, false)
+}
+
+func validateMax(value interface{}, param string) error {
+  return validateLimit(value, param, false, true)
+}
+
+func validateMinLen(value interface{}, param string) error {
+  return validateLimit(value, param, true, false)
+}
+
+func validateMaxLen(value interface{}, param string) error {
+  return validateLimit(value, param, true, true)
+}
+
+func validateLimit(value interface{}, param string, byLen, max bool) error {
+  limit, err := strconv.ParseFloat(param, 64)
+  if nil != err {
+    return fmt.Errorf("invalid limit %q", param)
+  }
+  size, isLen, err := validateSize(value, byLen)
+  if nil != err {
+    return err
+  }
+
+  var msg string
+  switch {
+  case max && size > limit:
+    msg = "must be at most " + param
+    break
+  case !max && size < limit:
+    msg = "must be at least " + param
+    break
+  default:
+    return nil
+  }
+  if isLen {
+    msg = "length " + msg
+  }
+  return errors.New(msg)
+}
+
+// validateSize returns the number or the length of the string, array
+// or object to compare with the limit. Numeric strings (e.g. values
+// of env variables) are numbers unless the length is requested.
+func validateSize(value interface{}, byLen bool) (size float64, isLen bool, err error) {
+  switch v := reflect.ValueOf(value); v.Kind() {
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    if !byLen {
+      return float64(v.Int()), false, nil
+    }
+    break
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+    if !byLen {
+      return float64(v.Uint()), false, nil
+    }
+    break
+  case reflect.Float32, reflect.Float64:
+    if !byLen {
+      return v.Float(), false, nil
+    }
+    break
+  case reflect.String:
+    if !byLen {
+      size, err = strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+      if nil == err && !math.IsNaN(size) && !math.IsInf(size, 0) {
+        return size, false, nil
+      }
+    }
+    return float64(len([]rune(v.String()))), true, nil
+  case reflect.Slice, reflect.Array, reflect.Map:
+    return float64(v.Len()), true, nil
+  }
+  if byLen {
+    return 0, false, errors.New("value must be string, array or object")
+  }
+  return 0, false, errors.New("value must be number, string, array or object")
+}
+
+func validateOneOf(value interface{}, param string) error {
+  if !isScalar(value) {
+    return errors.New("value must be scalar")
+  }
+  s := scalarToString(value)
+  for _, it := range strings.Fields(param) {
+    if it == s {
+      return nil
+    }
+  }
+  return fmt.Errorf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+}
+
+func validateRegex(value interface{}, param string) error {
+  rx, ok := validateRxs.Load(param)
+  if !ok {
+    compiled, err := regexp.Compile(param)
+    if nil != err {
+      return fmt.Errorf("invalid pattern: %v", err)
+    }
+    rx, _ = validateRxs.LoadOrStore(param, compiled)
+  }
+  if !isScalar(value) || !rx.(*regexp.Regexp).MatchString(scalarToString(value)) {
+    return fmt.Errorf("must match %s", param)
+  }
+  return nil
+}
+
+func validateURL(value interface{}, param string) error {
+  s, ok := value.(string)
+  if !ok {
+    return errors.New("must be URL string")
+  }
+  if u, err := url.Parse(s); nil != err || len(u.Scheme) < 1 || len(u.Host) < 1 {
+    return errors.New("must be absolute URL")
+  }
+  return nil
+}
+
+func validateHostname(value interface{}, param string) error {
+  s, ok := value.(string)
+  if !ok || len(s) > 253 || !hostnameEx.MatchString(s) {
+    return errors.New("must be valid hostname")
+  }
+  return nil
+}
@@ -0,0 +1,81 @@
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2015 Dmiptry Ponomarev <demdxx@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+
+package config
+
+import (
+  "errors"
+  "testing"
+)
+
+func TestValidateLimits(t *testing.T) {
+  conf := Config{
+    "db":   Config{"port": "70000", "name": "app", "password": "12345"},
+    "list": ConfigArr{1, 2, 3},
+    "inf":  "inf",
+  }
+
+  tests := []struct {
+    rules string
+    path  string
+    valid bool
+  }{
+    {path: "db.port", rules: "max=65535", valid: false},
+    {path: "db.port", rules: "min=1,max=70000", valid: true},
+    {path: "db.name", rules: "min=3,max=3", valid: true},
+    {path: "db.name", rules: "max=2", valid: false},
+    {path: "db.password", rules: "minlen=8", valid: false},
+    {path: "db.password", rules: "minlen=5,maxlen=5", valid: true},
+    {path: "db.password", rules: "min=8", valid: true},
+    {path: "list", rules: "min=3,maxlen=3", valid: true},
+    {path: "list", rules: "max=2", valid: false},
+    {path: "inf", rules: "max=2", valid: false},
+  }
+  for _, test := range tests {
+    err := conf.Validate(Schema{test.path: test.rules})
+    if test.valid != (nil == err) {
+      t.Errorf("%s %s: expected valid=%v, got %v", test.path, test.rules, test.valid, err)
+    }
+    var verrs ValidationErrors
+    if nil != err && !errors.As(err, &verrs) {
+      t.Errorf("%s %s: expected ValidationErrors, got %T", test.path, test.rules, err)
+    }
+  }
+}
+
+func TestValidateStruct(t *testing.T) {
+  type settings struct {
+    Port  int    `config:"port" validate:"required,min=1,max=65535"`
+    Level string `config:"level" validate:"oneof=debug info error"`
+  }
+
+  if err := Validate(&settings{Port: 80, Level: "info"}); nil != err {
+    t.Errorf("expected valid settings, got %v", err)
+  }
+  err := Validate(&settings{Port: 70000, Level: "trace"})
+  var verrs ValidationErrors
+  if !errors.As(err, &verrs) || 2 != len(verrs) {
+    t.Errorf("expected 2 validation errors, got %v", err)
+  }
+}